@@ -34,6 +34,22 @@ var AuthCSRFFunction = allino.NewFunction(
 		}, nil
 	})
 
+var AuthWritableFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/authwritable",
+		Method:      "POST",
+		ContentType: "application/json",
+		Auth:        allino.AuthWritable,
+		Summary:     "Requires a writable login, checked by Option.Auth",
+	},
+	func(r *allino.Runtime, param *AuthCSRFInput) (*AuthCSRFOutput, error) {
+		uid, _, _, _ := r.User()
+		return &AuthCSRFOutput{
+			User: uid,
+			Echo: param.Message,
+		}, nil
+	})
+
 var CORSHandler = allino.NewFunction(
 	allino.Option{
 		Path:        "/test/cors",
@@ -68,6 +68,36 @@ var ValidationAPIFunction = allino.NewFunction(
 		}, nil
 	})
 
+// スキーマに反映されるタグと例
+type SchemaExampleAPIInput struct {
+	Name  string `query:"name" validate:"required,max=32" description:"Display name" example:"Yotsuba"`
+	Email string `form:"email" validate:"required,email" description:"Contact address"`
+}
+
+type SchemaExampleAPIOutput struct {
+	Message string `json:"message"`
+}
+
+var SchemaExampleAPIFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/schema_example",
+		Method:      "POST",
+		ContentType: "application/json",
+		Summary:     "Schema tags and examples",
+		Examples: []allino.FunctionExample{
+			{
+				Summary: "valid input",
+				Input:   &SchemaExampleAPIInput{Name: "Yotsuba", Email: "yotsuba@example.com"},
+				Output:  &SchemaExampleAPIOutput{Message: "Hello Yotsuba <yotsuba@example.com>"},
+			},
+		},
+	},
+	func(r *allino.Runtime, param *SchemaExampleAPIInput) (*SchemaExampleAPIOutput, error) {
+		return &SchemaExampleAPIOutput{
+			Message: "Hello " + param.Name + " <" + param.Email + ">",
+		}, nil
+	})
+
 type ErrorTestAPIInput struct {
 	Mode string `query:"mode" validate:"required"` // "normal" or "code"
 }
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"github.com/wh-kuromai/allino"
+)
+
+type BodyItem struct {
+	Name  string `json:"name" xml:"name" validate:"required"`
+	Count int    `json:"count" xml:"count"`
+}
+
+type BodyAPIInput struct {
+	Item BodyItem `post:"auto"`
+}
+
+type BodyAPIOutput struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+var BodyAPIFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/body",
+		Method:      "POST",
+		ContentType: allino.JSON,
+		MaxBodySize: 256,
+	},
+	func(r *allino.Runtime, input *BodyAPIInput) (*BodyAPIOutput, error) {
+		return &BodyAPIOutput{
+			Name:  input.Item.Name,
+			Count: input.Item.Count,
+		}, nil
+	},
+)
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/wh-kuromai/allino"
+)
+
+// GroupAPI shares the prefix, method, content type and a request handler.
+var GroupAPI = allino.Group("/test/group", allino.Option{
+	Method:      "POST",
+	ContentType: allino.JSON,
+	CORS:        true,
+}).Use(func(r *allino.Runtime, input any) (bool, error) {
+	if r.Fiber() != nil && r.Fiber().Get("X-Group-Deny") != "" {
+		return false, allino.NewCodeError(403, "FORBIDDEN", "denied by group")
+	}
+	return false, nil
+})
+
+type GroupHelloInput struct {
+	Name string `form:"name"`
+}
+
+type GroupHelloOutput struct {
+	Hello string `json:"hello"`
+}
+
+var GroupHelloFunction = allino.NewFunction(
+	allino.Option{
+		Group:   GroupAPI,
+		Path:    "/hello",
+		Summary: "Function of a route group",
+	},
+	func(r *allino.Runtime, param *GroupHelloInput) (*GroupHelloOutput, error) {
+		return &GroupHelloOutput{Hello: param.Name}, nil
+	})
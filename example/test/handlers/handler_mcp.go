@@ -1,9 +1,16 @@
 package handlers
 
-import "github.com/wh-kuromai/allino"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wh-kuromai/allino"
+	"go.uber.org/zap"
+)
 
 type MCPToolInput struct {
-	Message string `json:"message" validate:"required"`
+	Message string `json:"message" validate:"required" description:"Message to echo" example:"hello"`
 }
 
 type MCPToolOutput struct {
@@ -16,8 +23,167 @@ var MCPToolFunction = allino.NewFunction(
 		Description: "Echoes a message for MCP tool tests.",
 		ContentType: allino.JSON,
 		MCP:         "tool",
+		Examples: []allino.FunctionExample{
+			{Input: &MCPToolInput{Message: "hello"}, Output: &MCPToolOutput{Echo: "hello"}},
+		},
 	},
 	func(r *allino.Runtime, input *MCPToolInput) (*MCPToolOutput, error) {
 		return &MCPToolOutput{Echo: input.Message}, nil
 	},
 )
+
+var MCPAsyncToolFunction = allino.NewFunction(
+	allino.Option{
+		Name:        "mcp_async_echo",
+		Description: "Echoes a message from an async job for MCP tool tests.",
+		ContentType: allino.JSON,
+		MCP:         "tool",
+		JobMode:     "dispatch",
+	},
+	func(r *allino.Runtime, input *MCPToolInput) (*MCPToolOutput, error) {
+		return &MCPToolOutput{Echo: input.Message}, nil
+	},
+)
+
+type MCPUserResourceInput struct {
+	ID     int    `path:"id" json:"id"`
+	Expand bool   `query:"expand" json:"expand"`
+	Lang   string `query:"lang"`
+}
+
+type MCPUserResourceOutput struct {
+	ID     int    `json:"id"`
+	Expand bool   `json:"expand"`
+	Lang   string `json:"lang"`
+}
+
+var MCPUserResourceFunction = allino.NewFunction(
+	allino.Option{
+		Name:        "users",
+		Description: "Looks up a user for MCP resource template tests.",
+		ContentType: allino.JSON,
+		MCP:         "resource",
+		MCPComplete: func(r *allino.Runtime, argument, value string, arguments map[string]string) ([]string, error) {
+			values := []string{}
+			if argument == "id" {
+				for _, id := range []string{"1", "2", "42"} {
+					if strings.HasPrefix(id, value) {
+						values = append(values, id)
+					}
+				}
+			}
+			return values, nil
+		},
+	},
+	func(r *allino.Runtime, input *MCPUserResourceInput) (*MCPUserResourceOutput, error) {
+		return &MCPUserResourceOutput{ID: input.ID, Expand: input.Expand, Lang: input.Lang}, nil
+	},
+)
+
+type MCPTouchUserInput struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type MCPTouchUserOutput struct {
+	URI string `json:"uri"`
+}
+
+var MCPTouchUserToolFunction = allino.NewFunction(
+	allino.Option{
+		Name:        "mcp_touch_user",
+		Description: "Notifies the subscribers of a user resource for MCP subscription tests.",
+		ContentType: allino.JSON,
+		MCP:         "tool",
+	},
+	func(r *allino.Runtime, input *MCPTouchUserInput) (*MCPTouchUserOutput, error) {
+		uri := fmt.Sprintf("allino://users/%d", input.ID)
+		r.NotifyResourceChanged(uri)
+		return &MCPTouchUserOutput{URI: uri}, nil
+	},
+)
+
+type MCPWaitInput struct {
+	Seconds int `json:"seconds"`
+}
+
+var MCPWaitToolFunction = allino.NewFunction(
+	allino.Option{
+		Name:        "mcp_wait",
+		Description: "Waits until cancelled for MCP cancellation tests.",
+		ContentType: allino.JSON,
+		MCP:         "tool",
+	},
+	func(r *allino.Runtime, input *MCPWaitInput) (*MCPToolOutput, error) {
+		r.Logger().Debug("mcp_wait started", zap.Int("seconds", input.Seconds))
+		select {
+		case <-r.Context().Done():
+			r.Logger().Warn("mcp_wait cancelled")
+			return nil, r.Context().Err()
+		case <-time.After(time.Duration(input.Seconds) * time.Second):
+			return &MCPToolOutput{Echo: "waited"}, nil
+		}
+	},
+)
+
+// MCPPixel is a 1x1 PNG for MCP image content tests.
+var MCPPixel = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+var MCPImageToolFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/mcp/image",
+		Method:      "GET",
+		Name:        "mcp_image",
+		Summary:     "Pixel",
+		Description: "Returns an image for MCP content tests.",
+		ContentType: "image/png",
+		MCP:         "tool",
+	},
+	func(r *allino.Runtime, input *struct{}) ([]byte, error) {
+		return MCPPixel, nil
+	},
+)
+
+type MCPReportOutput struct {
+	Title string   `json:"title"`
+	Rows  []string `json:"rows"`
+}
+
+func (o *MCPReportOutput) MCPContent() []allino.MCPContent {
+	return []allino.MCPContent{
+		allino.MCPText(o.Title),
+		allino.MCPResource("allino://report.csv", "text/csv", []byte(strings.Join(o.Rows, "\n"))),
+		allino.MCPResourceLink("allino://users/1", "users", allino.JSON),
+	}
+}
+
+var mcpNo = false
+
+var MCPReportToolFunction = allino.NewFunction(
+	allino.Option{
+		Name:           "mcp_report",
+		Description:    "Returns content blocks for MCP content tests.",
+		ContentType:    allino.JSON,
+		MCP:            "tool",
+		MCPAnnotations: &allino.MCPToolAnnotations{Title: "Report", DestructiveHint: &mcpNo},
+	},
+	func(r *allino.Runtime, input *struct{}) (*MCPReportOutput, error) {
+		return &MCPReportOutput{Title: "report", Rows: []string{"id,name", "1,alice"}}, nil
+	},
+)
+
+type MCPGreetingInput struct {
+	Name string `json:"name" validate:"required"`
+	Tone string `json:"tone" enum:"formal,friendly,funny"`
+}
+
+var MCPGreetingPromptFunction = allino.NewFunction(
+	allino.Option{
+		Name:        "mcp_greeting",
+		Description: "Writes a greeting for MCP completion tests.",
+		ContentType: allino.JSON,
+		MCP:         "prompt",
+	},
+	func(r *allino.Runtime, input *MCPGreetingInput) (string, error) {
+		return fmt.Sprintf("Write a %s greeting for %s.", input.Tone, input.Name), nil
+	},
+)
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"github.com/wh-kuromai/allino"
+)
+
+type ResponseFieldsInput struct {
+	Created bool `query:"created"`
+}
+
+type ResponseFieldsOutput struct {
+	Items   []string `json:"items"`
+	Total   int      `json:"-" header:"X-Total"`
+	Tags    []string `json:"-" header:"X-Tag"`
+	Visited string   `json:"-" cookie:"visited"`
+	Status  int      `json:"-" status:""`
+}
+
+var ResponseFieldsFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/response",
+		Method:      "GET",
+		ContentType: allino.JSON,
+	},
+	func(r *allino.Runtime, input *ResponseFieldsInput) (*ResponseFieldsOutput, error) {
+		out := &ResponseFieldsOutput{
+			Items:   []string{"a", "b"},
+			Total:   2,
+			Tags:    []string{"x", "y"},
+			Visited: "yes",
+		}
+		if input.Created {
+			out.Status = 201
+		}
+		return out, nil
+	},
+)
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/wh-kuromai/allino"
+)
+
+type StreamCountInput struct {
+	Count int `query:"count" json:"count" default:"3"`
+}
+
+type StreamCountOutput struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+var StreamCountFunction = allino.NewStreamFunction(
+	allino.Option{
+		Path:        "/test/stream",
+		Method:      "GET",
+		Name:        "stream_count",
+		Description: "Streams numbered items.",
+		MCP:         "tool",
+	},
+	func(r *allino.Runtime, input *StreamCountInput, stream *allino.Stream[*StreamCountOutput]) error {
+		for i := 1; i <= input.Count; i++ {
+			err := stream.Send(&StreamCountOutput{
+				Index: i,
+				Text:  "item-" + strconv.Itoa(i),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+)
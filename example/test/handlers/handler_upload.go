@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"io"
+
+	"github.com/wh-kuromai/allino"
+)
+
+type UploadAPIInput struct {
+	Note string         `form:"note"`
+	Doc  *allino.Upload `form:"doc" spool:"file"`
+	File io.Reader      `form:"file" maxsize:"1KB" mime:"text/*"`
+}
+
+type UploadAPIOutput struct {
+	Note     string `json:"note"`
+	Doc      string `json:"doc"`
+	DocName  string `json:"docName"`
+	File     string `json:"file"`
+	FileSize int64  `json:"fileSize"`
+}
+
+var UploadAPIFunction = allino.NewFunction(
+	allino.Option{
+		Path:        "/test/upload",
+		Method:      "POST",
+		ContentType: allino.JSON,
+	},
+	func(r *allino.Runtime, input *UploadAPIInput) (*UploadAPIOutput, error) {
+		out := &UploadAPIOutput{Note: input.Note}
+		if input.Doc != nil {
+			buf, err := io.ReadAll(input.Doc)
+			if err != nil {
+				return nil, err
+			}
+			out.Doc = string(buf)
+			out.DocName = input.Doc.Filename
+		}
+		if input.File != nil {
+			buf, err := io.ReadAll(input.File)
+			if err != nil {
+				return nil, err
+			}
+			out.File = string(buf)
+			out.FileSize = input.File.(*allino.Upload).Size
+		}
+		return out, nil
+	},
+)
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/wh-kuromai/allino"
+)
+
+type WebsocketEchoInput struct {
+	Room    string `path:"room" json:"-"`
+	Message string `json:"message" validate:"required"`
+	Upper   bool   `json:"upper"`
+}
+
+type WebsocketEchoOutput struct {
+	Room string `json:"room"`
+	Echo string `json:"echo"`
+}
+
+var WebsocketEchoFunction = allino.NewWebsocketFunction(
+	allino.Option{
+		Path:        "/test/ws/:room",
+		Name:        "ws_echo",
+		Description: "Echoes each message back to the sender.",
+	},
+	func(r *allino.Runtime, msg *WebsocketEchoInput) (*WebsocketEchoOutput, error) {
+		echo := msg.Message
+		if msg.Upper {
+			echo = strings.ToUpper(echo)
+		}
+		return &WebsocketEchoOutput{Room: msg.Room, Echo: echo}, nil
+	},
+)
@@ -0,0 +1,8 @@
+#!/bin/sh
+# Downloads the pinned documentation UI files embedded by typedhandler_openapi_docs.go.
+# Run with `go generate` before building a release, keep the versions in sync with the Go file.
+set -e
+cd "$(dirname "$0")"
+curl -fsSL -o swagger-ui.css https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14/swagger-ui.css
+curl -fsSL -o swagger-ui-bundle.js https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14/swagger-ui-bundle.js
+curl -fsSL -o redoc.standalone.js https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles/redoc.standalone.js
@@ -0,0 +1,6 @@
+// Starts Swagger UI with the document URL of the #swagger-ui element. It is a file, not an
+// inline script, so the docs page works under a Content-Security-Policy without 'unsafe-inline'.
+(function () {
+  var el = document.getElementById("swagger-ui");
+  SwaggerUIBundle({ url: el.dataset.url, dom_id: "#swagger-ui" });
+})();
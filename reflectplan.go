@@ -48,11 +48,16 @@ type fieldPlan struct {
 
 	// 非ポインタの struct フィールド用に、ネストを再帰的に解析した結果を保持
 	child *reflectPlan
+
+	// form:"xxx" io.Reader / *Upload フィールド
+	upload *uploadPlan
 }
 
 type reflectPlan struct {
-	typ    reflect.Type
-	fields []*fieldPlan
+	typ         reflect.Type
+	fields      []*fieldPlan
+	uploads     bool
+	fileHeaders bool // form:"xxx" *multipart.FileHeader fields, read from a parsed body
 }
 
 func buildPlan(t reflect.Type) *reflectPlan {
@@ -66,6 +71,8 @@ func buildPlan(t reflect.Type) *reflectPlan {
 	}
 
 	fields := make([]*fieldPlan, t.NumField())
+	uploads := false
+	fileHeaders := false
 
 	for i := 0; i < t.NumField(); i++ {
 		sf := t.Field(i)
@@ -109,11 +116,21 @@ func buildPlan(t reflect.Type) *reflectPlan {
 			fp.child = buildPlan(fp.basetyp)
 		}
 
+		if fp.tagoks[tagForm] && isUploadType(sf.Type) {
+			fp.upload = newUploadPlan(sf)
+			uploads = true
+		}
+		if fp.tagoks[tagForm] && (sf.Type == tFileHeaderPtr || sf.Type == tSliceFHPtr || sf.Type == reflect.PointerTo(tSliceFHPtr)) {
+			fileHeaders = true
+		}
+
 		fields[i] = fp
 	}
 
 	return &reflectPlan{
-		typ:    t,
-		fields: fields,
+		typ:         t,
+		fields:      fields,
+		uploads:     uploads,
+		fileHeaders: fileHeaders,
 	}
 }
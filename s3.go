@@ -12,6 +12,9 @@ import (
 type S3Config struct {
 	AWSRegion string          `json:"aws_region"`
 	Static    *S3StaticConfig `json:"static"`
+
+	UploadBucket string `json:"upload_bucket"` // spool:"s3" upload destination
+	UploadPrefix string `json:"upload_prefix"`
 }
 
 type S3StaticConfig struct {
@@ -94,6 +94,11 @@ type RoutingConfig struct {
 	FallbackPaths []string `json:"fallbacks"`
 	ErrorPath     string   `json:"error"`
 	Err404Path    string   `json:"404error"`
+
+	// API versioning of functions with Option.APIVersion
+	Versioning     string `json:"versioning"`     // "path" (default) or "header"
+	VersionHeader  string `json:"versionHeader"`  // default "Accept-Version"
+	DefaultVersion string `json:"defaultVersion"` // header versioning: version served without the header
 }
 
 type Server struct {
@@ -112,9 +117,12 @@ type Server struct {
 	ChatGPT    *openai.Client
 	Casbin     *casbin.SyncedEnforcer
 
-	nodeip     string
-	extensions []extendable
-	extopts    []*ExtOption
+	nodeip       string
+	extensions   []extendable
+	extopts      []*ExtOption
+	middlewares  []Middleware
+	uploadRoutes [][2]string // method, path of the functions with streaming uploads
+	streamUpload bool        // the request bodies are streamed for uploadRoutes
 
 	FunctionCache        []Function
 	internalHandlerCache []Function
@@ -258,6 +266,8 @@ func NewServer(config *Config, extconfig ...map[string]any) (*Server, error) {
 	if logmiddle != nil {
 		s.Fiber.Use(logmiddle)
 	}
+	// streaming is enabled when the first function with upload fields is registered.
+	s.Fiber.Use(s.limitStreamedBody)
 
 	s.TimeWheel = s.Config.TimeWheel.setup(s.appctx)
 
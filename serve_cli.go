@@ -94,6 +94,18 @@ func NewCLI(config *Config, extconfig ...map[string]any) *CLI {
 					injson = "{}"
 				}
 
+				streaming := s.handlerOptMap[handler].streaming
+				if streaming {
+					r.memo.streamSink = func(v any) error {
+						buf, err := json.Marshal(v)
+						if err != nil {
+							return err
+						}
+						fmt.Println(string(buf))
+						return nil
+					}
+				}
+
 				fmt.Printf("Running handler '%s'...\n", handler)
 				key, outjson, errjson, syserr := call_direct(s, r, handler, []byte(injson), func(input any) error {
 
@@ -115,7 +127,7 @@ func NewCLI(config *Config, extconfig ...map[string]any) *CLI {
 					return
 				}
 				fmt.Printf("JobID: %s\n", key)
-				if outjson != nil {
+				if outjson != nil && !streaming {
 					fmt.Print("Output:\n")
 					printJSON(outjson)
 					fmt.Print("\n")
@@ -161,31 +173,93 @@ func NewCLI(config *Config, extconfig ...map[string]any) *CLI {
 	}
 
 	if !isDisabled("openapi") {
-		rootCmd.AddCommand(&cobra.Command{
+		var version string
+		openapiCmd := &cobra.Command{
 			Use:   "openapi",
 			Short: "Generate OpenAPI YAML",
 			Run: func(cmd *cobra.Command, args []string) {
 				s := CLIServer(cmd, args)
 				s.RegisterAllFunction()
-				printOpenAPI(s)
+				printOpenAPI(s, version)
+			},
+		}
+		openapiCmd.Flags().StringVar(&version, "api-version", "", "Generate the document of one API version (Option.APIVersion)")
+		rootCmd.AddCommand(openapiCmd)
+	}
+
+	if !isDisabled("asyncapi") {
+		rootCmd.AddCommand(&cobra.Command{
+			Use:   "asyncapi",
+			Short: "Generate AsyncAPI YAML for WebSocket functions",
+			Run: func(cmd *cobra.Command, args []string) {
+				s := CLIServer(cmd, args)
+				s.RegisterAllFunction()
+				printAsyncAPI(s)
 			},
 		})
 	}
 
+	if !isDisabled("client") {
+		var pkg, output string
+		clientCmd := &cobra.Command{
+			Use:   "client",
+			Short: "Generate a typed Go client package",
+			Run: func(cmd *cobra.Command, args []string) {
+				s := CLIServer(cmd, args)
+				s.RegisterAllFunction()
+				if err := printClient(s, pkg, output); err != nil {
+					fmt.Println("Error:", err)
+					os.Exit(1)
+				}
+			},
+		}
+		clientCmd.Flags().StringVar(&pkg, "package", "client", "Package name of the generated client")
+		clientCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default: stdout)")
+		rootCmd.AddCommand(clientCmd)
+	}
+
+	if !isDisabled("typescript") {
+		var output string
+		tsCmd := &cobra.Command{
+			Use:     "typescript",
+			Aliases: []string{"ts"},
+			Short:   "Generate TypeScript types and a fetch client",
+			Run: func(cmd *cobra.Command, args []string) {
+				s := CLIServer(cmd, args)
+				s.RegisterAllFunction()
+				if err := printTypeScript(s, output); err != nil {
+					fmt.Println("Error:", err)
+					os.Exit(1)
+				}
+			},
+		}
+		tsCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default: stdout)")
+		rootCmd.AddCommand(tsCmd)
+	}
+
 	if !isDisabled("route") {
-		rootCmd.AddCommand(&cobra.Command{
+		var check bool
+		routeCmd := &cobra.Command{
 			Use:   "route",
 			Short: "Print registered routes",
 			Run: func(cmd *cobra.Command, args []string) {
 				s := CLIServer(cmd, args)
 				s.RegisterAllFunction()
+				if check {
+					if !printRouteCheck(s) {
+						os.Exit(1)
+					}
+					return
+				}
 				printRoute(s)
 			},
-		})
+		}
+		routeCmd.Flags().BoolVar(&check, "check", false, "Exit with status 1 on duplicate or ambiguous routes")
+		rootCmd.AddCommand(routeCmd)
 	}
 
 	if !isDisabled("mcp") {
-		rootCmd.AddCommand(&cobra.Command{
+		mcpCmd := &cobra.Command{
 			Use:   "mcp",
 			Short: "Print MCP endpoint and exposed items",
 			Run: func(cmd *cobra.Command, args []string) {
@@ -193,7 +267,36 @@ func NewCLI(config *Config, extconfig ...map[string]any) *CLI {
 				s.RegisterAllFunction()
 				printMCP(s)
 			},
-		})
+		}
+
+		var stdio bool
+		mcpServeCmd := &cobra.Command{
+			Use:   "serve",
+			Short: "Serve MCP over HTTP, or over stdin/stdout with --stdio",
+			Run: func(cmd *cobra.Command, args []string) {
+				if !stdio {
+					s := CLIServer(cmd, args)
+					s.RegisterAllFunction()
+					s.Serve()
+					return
+				}
+
+				// stdout carries the protocol, logs and messages go to stderr.
+				stdout := os.Stdout
+				os.Stdout = os.Stderr
+
+				s := CLIServer(cmd, args)
+				s.RegisterAllFunction()
+				s.serveInitOnly()
+				if err := s.ServeMCPStdio(os.Stdin, stdout); err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+					os.Exit(1)
+				}
+			},
+		}
+		mcpServeCmd.Flags().BoolVar(&stdio, "stdio", false, "Serve newline-delimited JSON-RPC on stdin/stdout for local agents")
+		mcpCmd.AddCommand(mcpServeCmd)
+		rootCmd.AddCommand(mcpCmd)
 	}
 
 	if !isDisabled("version") {
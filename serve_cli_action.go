@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
@@ -11,8 +12,9 @@ import (
 )
 
 type routeKey struct {
-	Method string
-	Path   string
+	Method  string
+	Path    string
+	Version string
 }
 
 func printRoute(s *Server) {
@@ -30,14 +32,18 @@ Note:
 	counts := map[routeKey]int{}
 
 	for _, r := range allh {
-		key := routeKey{r.Method, r.Path}
+		key := routeKey{r.Method, r.RoutePath(), r.APIVersion}
 		counts[key]++
 	}
 
 	// 👇 2. グルーピング（前のやつ）
 	grouped := map[string][]*Option{}
 	for _, r := range allh {
-		grouped[r.Package] = append(grouped[r.Package], r)
+		heading := cleanPkg(r.Package)
+		if r.Group != nil {
+			heading = r.Group.Name + " (group " + r.Group.Prefix() + ")"
+		}
+		grouped[heading] = append(grouped[heading], r)
 	}
 
 	var packages []string
@@ -48,21 +54,21 @@ Note:
 
 	// 👇 3. 出力
 	for _, pkg := range packages {
-		fmt.Printf("## %s\n", cleanPkg(pkg))
+		fmt.Printf("## %s\n", pkg)
 
 		handlers := grouped[pkg]
 
 		sort.Slice(handlers, func(i, j int) bool {
-			if handlers[i].Path == handlers[j].Path {
+			if handlers[i].RoutePath() == handlers[j].RoutePath() {
 				return handlers[i].Method < handlers[j].Method
 			}
-			return handlers[i].Path < handlers[j].Path
+			return handlers[i].RoutePath() < handlers[j].RoutePath()
 		})
 
 		for _, r := range handlers {
 			line, form := generateRouteFromOptions(r)
 
-			key := routeKey{r.Method, r.Path}
+			key := routeKey{r.Method, r.RoutePath(), r.APIVersion}
 			dup := counts[key] > 1
 
 			if r.Summary == "" {
@@ -87,9 +93,27 @@ Note:
 		fmt.Println()
 	}
 }
-func printOpenAPI(s *Server) {
+
+// printRouteCheck prints route conflicts and returns false if there are any.
+func printRouteCheck(s *Server) bool {
+	conflicts := s.CheckRoutes()
+	for _, c := range conflicts {
+		fmt.Println(c.String())
+	}
+	if len(conflicts) > 0 {
+		fmt.Printf("%d route conflict(s) found\n", len(conflicts))
+		return false
+	}
+	fmt.Println("no route conflicts")
+	return true
+}
+
+func printOpenAPI(s *Server, version string) {
 
 	schema := s.GenerateOpenAPI()
+	if version != "" {
+		schema = s.GenerateOpenAPIVersion(version)
+	}
 
 	//jsonBytes, _ := json.MarshalIndent(schema, "", "  ")
 	//var intermediate OpenAPI
@@ -99,6 +123,37 @@ func printOpenAPI(s *Server) {
 	fmt.Print(string(yamlBytes))
 }
 
+// printClient writes the generated client to output, or to stdout.
+func printClient(s *Server, pkg, output string) error {
+	src, err := s.GenerateClient(pkg)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Print(string(src))
+		return nil
+	}
+	return os.WriteFile(output, src, 0644)
+}
+
+// printTypeScript writes the generated TypeScript to output, or to stdout.
+func printTypeScript(s *Server, output string) error {
+	src, err := s.GenerateTypeScript()
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Print(src)
+		return nil
+	}
+	return os.WriteFile(output, []byte(src), 0644)
+}
+
+func printAsyncAPI(s *Server) {
+	yamlBytes, _ := yaml.Marshal(s.GenerateAsyncAPI())
+	fmt.Print(string(yamlBytes))
+}
+
 func printMCP(s *Server) {
 	config := mcpConfig()
 	endpoint := mcpEndpoint()
@@ -110,10 +165,12 @@ func printMCP(s *Server) {
 
 	fmt.Print("MCP Endpoint:\n")
 	fmt.Printf("  POST %s\n", endpoint)
-	fmt.Print("Metadata Endpoint:\n")
+	fmt.Print("Metadata / Stream Endpoint:\n")
 	fmt.Printf("  GET %s\n", endpoint)
+	fmt.Print("Session Termination:\n")
+	fmt.Printf("  DELETE %s\n", endpoint)
 	fmt.Print("Protocol:\n")
-	fmt.Print("  2024-11-05\n")
+	fmt.Printf("  %s\n", strings.Join(mcpProtocolVersions, ", "))
 	fmt.Print("Transport:\n")
 	fmt.Print("  streamable-http\n")
 	fmt.Print("Resource URI:\n")
@@ -140,7 +197,7 @@ func printMCP(s *Server) {
 }
 
 func printMCPTools(s *Server) {
-	result, err := mcpToolsList(s)
+	result, err := mcpToolsList(s, nil)
 	if err != nil {
 		fmt.Printf("## Tools\nError: %v\n\n", err)
 		return
@@ -171,7 +228,7 @@ func printMCPTools(s *Server) {
 }
 
 func printMCPPrompts(s *Server) {
-	result, err := mcpPromptsList(s)
+	result, err := mcpPromptsList(s, nil)
 	if err != nil {
 		fmt.Printf("## Prompts\nError: %v\n\n", err)
 		return
@@ -199,13 +256,19 @@ func printMCPPrompts(s *Server) {
 }
 
 func printMCPResources(s *Server) {
-	result, err := mcpResourcesList(s)
+	result, err := mcpResourcesList(s, nil)
 	if err != nil {
 		fmt.Printf("## Resources\nError: %v\n\n", err)
 		return
 	}
 
 	resources := mcpListFromResult(result, "resources")
+	if templates, err := mcpResourceTemplatesList(s, nil); err == nil {
+		for _, item := range mcpListFromResult(templates, "resourceTemplates") {
+			item["uri"] = item["uriTemplate"]
+			resources = append(resources, item)
+		}
+	}
 	fmt.Print("## Resources\n")
 	if len(resources) == 0 {
 		fmt.Print("(none)\n\n")
@@ -27,6 +27,7 @@ type ExtOption struct {
 	RequestHandler  func(r *Runtime, opt *Option, input any) (consumed bool, err error)
 	ResponseHandler func(r *Runtime, opt *Option, output any) (consumed bool)
 	ErrorHandler    func(r *Runtime, opt *Option, err error) (consumed bool)
+	Middleware      Middleware
 	CLICommands     []*cobra.Command
 
 	//IsCallTarget func(opt *Option) bool
@@ -29,13 +29,17 @@ const (
 )
 
 func (s *Server) HandleRequestFunc(method, pattern string, handlerfunc func(*Runtime)) {
-	s.Fiber.Add(method, pattern, func(w *fiber.Ctx) error {
+	s.Fiber.Add(method, pattern, s.requestHandler(handlerfunc))
+}
+
+func (s *Server) requestHandler(handlerfunc func(*Runtime)) fiber.Handler {
+	return func(w *fiber.Ctx) error {
 		req := NewRuntime(s, w)
 		defer req.do_defer()
 		req.cache.req_type = REQUEST_HTTP
 		handlerfunc(req)
 		return nil
-	})
+	}
 }
 
 //func (s *Server) TypedHandle(th Function) {
@@ -31,6 +31,29 @@ type WebsocketRequestHandler func(r *Runtime) error
 type WebsocketConnHandler func(r *Runtime, conn *websocket.Conn)
 
 func (s *Server) HandleWebsocket(pattern string, requestHandlerFunc WebsocketRequestHandler, connHandlerFunc WebsocketConnHandler, c ...websocket.Config) {
+	cfg := s.websocketConfig(c...)
+
+	s.Fiber.Use(pattern, func(c *fiber.Ctx) error {
+		req := NewRuntime(s, c)
+		defer req.do_defer()
+		req.cache.req_type = REQUEST_WS
+		err := requestHandlerFunc(req)
+		if err != nil {
+			return nil
+		}
+
+		c.Locals("allino", req)
+		return c.Next()
+	})
+
+	s.Fiber.Use(pattern, websocket.New(func(c *websocket.Conn) {
+		defer c.Close()
+		req := c.Locals("allino").(*Runtime)
+		connHandlerFunc(req, c)
+	}, cfg))
+}
+
+func (s *Server) websocketConfig(c ...websocket.Config) websocket.Config {
 	var cfg websocket.Config
 	if len(c) >= 1 {
 		cfg = c[0]
@@ -54,23 +77,5 @@ func (s *Server) HandleWebsocket(pattern string, requestHandlerFunc WebsocketReq
 	if s.Config.WebSocket.EnableCompression != nil {
 		cfg.EnableCompression = *s.Config.WebSocket.EnableCompression
 	}
-
-	s.Fiber.Use(pattern, func(c *fiber.Ctx) error {
-		req := NewRuntime(s, c)
-		defer req.do_defer()
-		req.cache.req_type = REQUEST_WS
-		err := requestHandlerFunc(req)
-		if err != nil {
-			return nil
-		}
-
-		c.Locals("allino", req)
-		return c.Next()
-	})
-
-	s.Fiber.Use(pattern, websocket.New(func(c *websocket.Conn) {
-		defer c.Close()
-		req := c.Locals("allino").(*Runtime)
-		connHandlerFunc(req, c)
-	}, cfg))
+	return cfg
 }
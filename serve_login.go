@@ -90,9 +90,17 @@ func (c *CookieConfig) ToFasthttpCookie(value string) *fasthttp.Cookie {
 	return cookie
 }
 
+const (
+	AuthLogin    = "login"    // Option.Auth: requires a logged-in user
+	AuthWritable = "writable" // Option.Auth: requires an access token, or a login cookie with CSRF token
+)
+
 var (
 	ErrNoPublicKey = errors.New("Config login.publickey or login.privatekey has not valid key")
 	ErrNotLoggedIn = errors.New("User not logged-in")
+
+	ErrLoginRequired    = NewCodeError(401, "login_required", "login required")
+	ErrWritableRequired = NewCodeError(403, "csrf_token_required", "csrf token or access token required")
 )
 
 func (c *LoginConfig) setup() error {
@@ -174,10 +182,16 @@ func (r *Runtime) ClaimUnmarshal(obj any) error {
 }
 
 func (r *Runtime) userWithJWT() (uid, displayname string, writable bool, jwtbody []byte, err error) {
-	du := r.fiber.Query(".user")
-	if r.config.Debug && du != "" {
-		r.cache.authorizedBy = "debug"
-		return du, du, true, nil, nil
+	if r.fiber != nil {
+		du := r.fiber.Query(".user")
+		if r.config.Debug && du != "" {
+			r.cache.authorizedBy = "debug"
+			r.cache.cachedLogin = true
+			r.cache.cachedUid = du
+			r.cache.cachedName = du
+			r.cache.cachedWritable = true
+			return du, du, true, nil, nil
+		}
 	}
 
 	if r.cache.cachedLogin {
@@ -224,6 +238,11 @@ func (r *Runtime) user() (jwt *cryptino.JSONWebToken, writable bool, err error)
 		//return "", "", false, nil, ErrNoPublicKey
 	}
 
+	// cron, jobs and MCP over stdio have no request to read a token from.
+	if r.fiber == nil {
+		return nil, false, ErrNotLoggedIn
+	}
+
 	accessToken := ""
 	if r.config.Login.OAuth.QueryKey != "" {
 		accessToken = r.fiber.Query(r.config.Login.OAuth.QueryKey)
@@ -290,6 +309,22 @@ func (r *Runtime) user() (jwt *cryptino.JSONWebToken, writable bool, err error)
 	//return "", "", false, nil, ErrNotLoggedIn
 }
 
+// enforceAuth checks Option.Auth against the current user.
+func (r *Runtime) enforceAuth(opt *Option) error {
+	if opt == nil || opt.Auth == "" {
+		return nil
+	}
+
+	_, _, writable, err := r.User()
+	if err != nil {
+		return ErrLoginRequired
+	}
+	if opt.Auth == AuthWritable && !writable {
+		return ErrWritableRequired
+	}
+	return nil
+}
+
 func (r *Runtime) SessionID(setcookie bool) string {
 	if r.cache.sessionid != "" {
 		return r.cache.sessionid
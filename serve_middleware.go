@@ -1 +1,45 @@
 package allino
+
+// Middleware wraps every invocation of a function: HTTP, Function.Call, jobs, cron, MCP and CLI.
+// next runs the rest of the chain and the function, it can be called again to retry.
+// The returned output replaces the function output when it has the output type.
+type Middleware func(r *Runtime, opt *Option, input any, next func() (any, error)) (any, error)
+
+// Use adds middleware wrapping all functions of the server, before extension and Option middleware.
+// Call Use before serving.
+func (s *Server) Use(middleware ...Middleware) {
+	s.middlewares = append(s.middlewares, middleware...)
+}
+
+// middlewareChain returns server, extension and Option middleware in execution order, or nil.
+func middlewareChain(r *Runtime, opt *Option) []Middleware {
+	var chain []Middleware
+	if r != nil && r.server != nil {
+		chain = append(chain, r.server.middlewares...)
+		for _, ext := range r.server.extopts {
+			if ext.Middleware != nil {
+				chain = append(chain, ext.Middleware)
+			}
+		}
+	}
+	return append(chain, opt.Middleware...)
+}
+
+// runMiddleware calls fn through the middleware chain. A typed nil error of fn is returned as
+// nil, so middleware can check err != nil.
+func runMiddleware(r *Runtime, opt *Option, chain []Middleware, input any, fn func() (any, error)) (any, error) {
+	var next func(i int) (any, error)
+	next = func(i int) (any, error) {
+		if i == len(chain) {
+			out, err := fn()
+			if isReallyNil(err) {
+				err = nil
+			}
+			return out, err
+		}
+		return chain[i](r, opt, input, func() (any, error) {
+			return next(i + 1)
+		})
+	}
+	return next(0)
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"mime/multipart"
 	"net/http"
@@ -64,6 +63,10 @@ type requestMemo struct {
 	jobid          string
 	jobabortctrl   string
 	jobrequeuewait int
+	streamSink     func(v any) error
+	mcpSession     *mcpSession
+	mcpSend        func(buf []byte) error // writes to the transport of the current MCP request
+	ctx            context.Context
 }
 
 type requestCache struct {
@@ -98,8 +101,9 @@ type requestCache struct {
 	jwtdecodedbytag    map[string]json.RawMessage
 
 	//extopts []*ExtOption
-	body    []byte
-	deferfn []func() error
+	body       []byte
+	formvalues map[string]string // streamed multipart form values
+	deferfn    []func() error
 }
 
 func NewRuntime(s *Server, w *fiber.Ctx) *Runtime {
@@ -240,6 +244,9 @@ func cachedRegex(rx string) (*regexp.Regexp, error) {
 }
 
 func (r *Runtime) Context() context.Context {
+	if r.memo.ctx != nil {
+		return r.memo.ctx
+	}
 	if r.fiber != nil {
 		return r.fiber.UserContext()
 	}
@@ -250,6 +257,20 @@ func (r *Runtime) Context() context.Context {
 	return r.server.appctx
 }
 
+// detached returns a copy of r without the fiber context, for code running after the handler
+// returned and fiber released the context, e.g. a body stream writer. The login, client ip,
+// request id and context are resolved while the request is still available.
+func (r *Runtime) detached() *Runtime {
+	r.User()
+	r.cache.clientip = strings.Clone(r.ClientIP())
+	r.cache.requestid = strings.Clone(r.RequestID())
+	r.memo.ctx = r.Context()
+
+	dr := *r
+	dr.fiber = nil
+	return &dr
+}
+
 func (r *Runtime) jwtdecodedbytag(jwtbody []byte) (map[string]json.RawMessage, error) {
 	if r.cache.jwtdecodedbytag == nil {
 		var out map[string]json.RawMessage
@@ -325,6 +346,10 @@ func (r *Runtime) getByStructField(rpf *fieldPlan, fieldVal reflect.Value) (stri
 	qx = rpf.tags[tagForm]
 	ok = rpf.tagoks[tagForm]
 	if ok {
+		if r.cache.formvalues != nil {
+			return r.cache.formvalues[qx], true, nil
+		}
+
 		ct := strings.ToLower(r.fiber.Get("Content-Type"))
 		if strings.HasPrefix(ct, "application/x-www-form-urlencoded") ||
 			strings.HasPrefix(ct, "multipart/form-data") {
@@ -348,35 +373,39 @@ func (r *Runtime) getByStructField(rpf *fieldPlan, fieldVal reflect.Value) (stri
 				target = fieldVal.Interface()
 			}
 
-			ct := strings.ToLower(r.fiber.Get("Content-Type"))
-			switch qx {
-			case "json":
-				if !strings.HasPrefix(ct, "application/json") &&
-					!strings.HasPrefix(ct, "text/") {
-					return "", false, nil
-				}
-
-				err := json.Unmarshal(buf, target)
-				if err != nil {
-					return "", false, errors.New("failed to unmarshal JSON body: " + err.Error())
+			if qx == "raw" {
+				if fieldVal.Kind() == reflect.Slice && fieldVal.Type().Elem().Kind() == reflect.Uint8 {
+					fieldVal.SetBytes(buf)
 				}
 				return "", false, nil
-			case "xml":
-				if !strings.HasPrefix(ct, "application/xml") &&
-					!strings.HasPrefix(ct, "text/") {
-					return "", false, nil
-				}
-				err := xml.Unmarshal(buf, target)
-				if err != nil {
-					return "", false, errors.New("failed to unmarshal XML body: " + err.Error())
+			}
+
+			format := postBodyFormat(qx, r.fiber.Get("Content-Type"))
+			if format == "" {
+				if qx == "auto" {
+					return "", false, &BodyDecodeError{
+						Status: ErrUnsupportedMediaType.Status,
+						Code:   ErrUnsupportedMediaType.Code,
+						Msg:    "unsupported content type: " + r.fiber.Get("Content-Type"),
+						Field:  rpf.name,
+						Format: qx,
+						Err:    ErrUnsupportedMediaType,
+					}
 				}
 				return "", false, nil
-			case "raw":
-				if fieldVal.Kind() == reflect.Slice && fieldVal.Type().Elem().Kind() == reflect.Uint8 {
-					fieldVal.SetBytes(buf)
-					return "", false, nil
+			}
+
+			err := decodePostBody(format, buf, target)
+			if err != nil {
+				return "", false, &BodyDecodeError{
+					Code:   "invalid_body",
+					Msg:    "failed to unmarshal " + strings.ToUpper(format) + " body: " + err.Error(),
+					Field:  rpf.name,
+					Format: format,
+					Err:    err,
 				}
 			}
+			return "", false, nil
 		}
 	}
 
@@ -455,6 +484,10 @@ var (
 )
 
 func (r *Runtime) getAll(params interface{}, rp *reflectPlan) error {
+	return r.getAllWithValidate(params, rp, !r.config.System.DisableValidator)
+}
+
+func (r *Runtime) getAllWithValidate(params interface{}, rp *reflectPlan, validate bool) error {
 	if params == nil {
 		return ErrNotStruct
 	}
@@ -471,6 +504,12 @@ func (r *Runtime) getAll(params interface{}, rp *reflectPlan) error {
 
 	injectTarget := make([]*InjectionTarget, 0, 10)
 
+	if rp.uploads {
+		if err := r.bindStreamingUploads(pv, rp); err != nil {
+			return err
+		}
+	}
+
 	for i := 0; i < pt.NumField(); i++ {
 		ptf := pt.Field(i)
 		// 非公開フィールドはスキップ（タグ付けされていても set できないため）
@@ -480,6 +519,11 @@ func (r *Runtime) getAll(params interface{}, rp *reflectPlan) error {
 		rpf := rp.fields[i]
 		ptv := pv.Field(i)
 
+		// io.Reader / *Upload (bindStreamingUploads)
+		if rpf.upload != nil {
+			continue
+		}
+
 		// if *multipart.FileHeader
 		if rpf.typ == tFileHeaderPtr {
 			qx := rpf.tags[tagForm]   //.Tag.Lookup("form")
@@ -591,7 +635,7 @@ func (r *Runtime) getAll(params interface{}, rp *reflectPlan) error {
 		setByReflect(pfval, rpf.ispointer, rpf.basetyp, ptv)
 	}
 
-	if !r.config.System.DisableValidator {
+	if validate {
 		if err := r.server.Validator.Struct(params); err != nil {
 			return ErrValidationFailed
 		}
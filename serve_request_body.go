@@ -0,0 +1,140 @@
+package allino
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	XML     = "application/xml"
+	MsgPack = "application/msgpack"
+	YAML    = "application/yaml"
+)
+
+var (
+	ErrBodyTooLarge         = NewCodeError(413, "body_too_large", "request body too large")
+	ErrUnsupportedMediaType = NewCodeError(415, "unsupported_media_type", "unsupported media type")
+)
+
+// BodyDecodeError is returned when the request body of a `post` tagged field can not be decoded.
+type BodyDecodeError struct {
+	Status int    `json:"-"`
+	Code   string `json:"code"`
+	Msg    string `json:"msg"`
+	Field  string `json:"field,omitempty"`
+	Format string `json:"format,omitempty"`
+	Err    error  `json:"-"`
+}
+
+func (e *BodyDecodeError) StatusCode() int {
+	if e.Status == 0 {
+		return 400
+	}
+	return e.Status
+}
+
+func (e *BodyDecodeError) ErrorCode() string {
+	return e.Code
+}
+
+func (e *BodyDecodeError) Error() string {
+	return e.Msg
+}
+
+func (e *BodyDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// requestBodySize returns Content-Length without reading a streamed body. A chunked body is
+// only streamed to upload routes, where its size is unknown (-1) and maxsize applies per part.
+func (r *Runtime) requestBodySize() int {
+	if n := r.fiber.Request().Header.ContentLength(); n >= 0 {
+		return n
+	}
+	if r.fiber.Context().RequestBodyStream() != nil {
+		return -1
+	}
+	return len(r.bodyBytes())
+}
+
+// post:"xxx" format -> accepted Content-Type prefixes
+var postBodyContentTypes = map[string][]string{
+	"json":    {JSON, "text/"},
+	"xml":     {XML, "text/"},
+	"msgpack": {MsgPack, "application/x-msgpack", "application/vnd.msgpack"},
+	"yaml":    {YAML, "application/x-yaml", "text/yaml", "text/x-yaml"},
+}
+
+// postBodyFormat returns the body format for the Content-Type, "" when it does not match.
+// "auto" picks the format from the Content-Type itself.
+func postBodyFormat(format, ct string) string {
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	if format == "auto" {
+		return autoPostBodyFormat(ct)
+	}
+
+	for _, prefix := range postBodyContentTypes[format] {
+		if strings.HasPrefix(ct, prefix) {
+			return format
+		}
+	}
+	return ""
+}
+
+func autoPostBodyFormat(ct string) string {
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = strings.TrimSpace(ct[:i])
+	}
+
+	switch {
+	case ct == "", ct == JSON, ct == "text/json", strings.HasSuffix(ct, "+json"):
+		return "json"
+	case ct == XML, ct == "text/xml", strings.HasSuffix(ct, "+xml"):
+		return "xml"
+	case ct == YAML, ct == "application/x-yaml", ct == "text/yaml", ct == "text/x-yaml":
+		return "yaml"
+	case ct == MsgPack, ct == "application/x-msgpack", ct == "application/vnd.msgpack":
+		return "msgpack"
+	}
+	return ""
+}
+
+func decodePostBody(format string, buf []byte, target any) error {
+	switch format {
+	case "json":
+		return json.Unmarshal(buf, target)
+	case "xml":
+		return xml.Unmarshal(buf, target)
+	case "yaml":
+		return yaml.Unmarshal(buf, target)
+	case "msgpack":
+		dec := msgpack.NewDecoder(bytes.NewReader(buf))
+		dec.SetCustomStructTag("json")
+		return dec.Decode(target)
+	}
+	return ErrUnsupportedMediaType
+}
+
+// postBodyMediaTypes returns the Content-Types documented for a post:"xxx" field.
+func postBodyMediaTypes(format string) []string {
+	switch format {
+	case "json":
+		return []string{JSON}
+	case "xml":
+		return []string{XML}
+	case "msgpack":
+		return []string{MsgPack}
+	case "yaml":
+		return []string{YAML}
+	case "auto":
+		return []string{JSON, XML, MsgPack, YAML}
+	case "raw":
+		return []string{"application/octet-stream"}
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package allino
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/xid"
+)
+
+var (
+	tUploadPtr = reflect.TypeOf((*Upload)(nil))           // *allino.Upload
+	tIOReader  = reflect.TypeOf((*io.Reader)(nil)).Elem() // io.Reader
+
+	ErrUploadTooLarge     = NewCodeError(413, "upload_too_large", "upload too large")
+	ErrUploadMIMEType     = NewCodeError(415, "upload_mime_type", "upload mime type not allowed")
+	ErrUploadSpoolFailed  = NewCodeError(500, "upload_spool_failed", "upload spool failed")
+	ErrInvalidMultipart   = NewCodeError(400, "invalid_multipart", "invalid multipart body")
+	ErrS3UploadNotEnabled = NewCodeError(500, "s3_upload_not_configured", "s3 upload bucket is not configured")
+)
+
+const (
+	uploadMaxFormValueSize = 1 << 20
+	uploadS3PartSize       = 8 << 20 // S3 requires >= 5MB except the last part
+)
+
+// Upload is a multipart file part bound to a form:"name" field of type *allino.Upload or io.Reader.
+// The part is read from the request body while the function runs, it is never buffered as a whole.
+//
+//	File *allino.Upload `form:"file" maxsize:"5GB" mime:"video/*,application/zip" spool:"s3"`
+//
+// The last upload field in the struct is streamed live; earlier ones (or spool:"file") are
+// copied to a temporary file, spool:"s3" copies the part to s3.upload_bucket.
+// Form values sent after a live streamed part are not bound, so send the file last.
+type Upload struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"` // bytes read so far
+	Bucket      string `json:"bucket,omitempty"`
+	Key         string `json:"key,omitempty"`
+
+	r      *Runtime
+	reader io.Reader
+}
+
+func (u *Upload) Read(p []byte) (int, error) {
+	if u.reader == nil {
+		if u.Key == "" {
+			return 0, io.EOF
+		}
+
+		obj, err := u.r.S3().GetObject(u.r.Context(), &s3.GetObjectInput{
+			Bucket: aws.String(u.Bucket),
+			Key:    aws.String(u.Key),
+		})
+		if err != nil {
+			return 0, err
+		}
+		u.reader = obj.Body
+		u.r.Defer(obj.Body.Close)
+	}
+	return u.reader.Read(p)
+}
+
+type uploadPlan struct {
+	maxSize ByteSize
+	mimes   []string
+	spool   string // "", "file", "s3"
+}
+
+func isUploadType(t reflect.Type) bool {
+	return t == tUploadPtr || t == tIOReader
+}
+
+func newUploadPlan(sf reflect.StructField) *uploadPlan {
+	up := &uploadPlan{
+		spool: sf.Tag.Get("spool"),
+	}
+	if v, ok := sf.Tag.Lookup("maxsize"); ok {
+		size, err := ParseByteSize(v)
+		if err != nil {
+			panic("invalid maxsize tag on " + sf.Name + ": " + err.Error())
+		}
+		up.maxSize = size
+	}
+	if v := sf.Tag.Get("mime"); v != "" {
+		for _, m := range strings.Split(v, ",") {
+			up.mimes = append(up.mimes, strings.ToLower(strings.TrimSpace(m)))
+		}
+	}
+	return up
+}
+
+func (up *uploadPlan) allowMIME(ct string) bool {
+	if len(up.mimes) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mt = ct
+	}
+	mt = strings.ToLower(mt)
+
+	for _, m := range up.mimes {
+		if m == mt || m == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(m, "/*") && strings.HasPrefix(mt, m[:len(m)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// enableStreamingUpload streams request bodies once a function with upload fields is
+// registered. Functions may be registered after NewServer, so it is not a Fiber config.
+func (s *Server) enableStreamingUpload() {
+	if s.streamUpload {
+		return
+	}
+	s.streamUpload = true
+	server := s.Fiber.Server()
+	server.StreamRequestBody = true
+	// a pre-parsed form is re-encoded in random part order, keep the raw body instead.
+	server.DisablePreParseMultipartForm = true
+}
+
+// limitStreamedBody keeps Fiber.BodyLimit for the routes without streaming uploads. Once
+// StreamRequestBody is enabled, fasthttp streams larger bodies instead of rejecting them.
+func (s *Server) limitStreamedBody(c *fiber.Ctx) error {
+	stream := c.Context().RequestBodyStream()
+	if !s.streamUpload || stream == nil || s.isUploadRoute(c.Method(), c.Path()) {
+		return c.Next()
+	}
+
+	limit := s.Fiber.Config().BodyLimit
+	body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+	if err != nil {
+		return fiber.ErrBadRequest
+	}
+	if len(body) > limit {
+		// the rest of the body is not read, the connection cannot be reused.
+		c.Context().SetConnectionClose()
+		return fiber.ErrRequestEntityTooLarge
+	}
+	c.Request().SetBody(body)
+	return c.Next()
+}
+
+func (s *Server) isUploadRoute(method, path string) bool {
+	for _, route := range s.uploadRoutes {
+		if route[0] == method && fiber.RoutePatternMatch(path, route[1], s.Fiber.Config()) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindStreamingUploads reads a multipart/form-data body part by part and binds the upload fields.
+// Other form values are kept in r.cache.formvalues for getByStructField.
+func (r *Runtime) bindStreamingUploads(pv reflect.Value, rp *reflectPlan) error {
+	mt, params, err := mime.ParseMediaType(r.fiber.Get("Content-Type"))
+	if err != nil || mt != "multipart/form-data" {
+		return nil
+	}
+
+	stream := r.fiber.Context().RequestBodyStream()
+	if stream == nil {
+		stream = bytes.NewReader(r.fiber.Body())
+	}
+	mr := multipart.NewReader(stream, params["boundary"])
+
+	fields := map[string]int{}
+	last := -1
+	for i, fp := range rp.fields {
+		if fp != nil && fp.upload != nil {
+			fields[fp.tags[tagForm]] = i
+			last = i
+		}
+	}
+
+	r.cache.formvalues = map[string]string{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidMultipart
+		}
+
+		name := part.FormName()
+		i, ok := fields[name]
+		if !ok || part.FileName() == "" {
+			if part.FileName() == "" {
+				buf, err := io.ReadAll(io.LimitReader(part, uploadMaxFormValueSize))
+				if err != nil {
+					return ErrInvalidMultipart
+				}
+				r.cache.formvalues[name] = string(buf)
+			}
+			part.Close()
+			continue
+		}
+
+		fp := rp.fields[i]
+		u, err := r.newUpload(part, fp.upload)
+		if err != nil {
+			return err
+		}
+
+		if i == last && fp.upload.spool == "" {
+			// remaining parts stay in the body stream.
+			pv.Field(i).Set(reflect.ValueOf(u))
+			return nil
+		}
+
+		if fp.upload.spool == "s3" {
+			err = r.spoolUploadS3(u)
+		} else {
+			err = r.spoolUploadFile(u)
+		}
+		if err != nil {
+			return err
+		}
+		pv.Field(i).Set(reflect.ValueOf(u))
+	}
+}
+
+func (r *Runtime) newUpload(part *multipart.Part, up *uploadPlan) (*Upload, error) {
+	u := &Upload{
+		r:           r,
+		Field:       part.FormName(),
+		Filename:    part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+	}
+
+	br := bufio.NewReader(part)
+	if u.ContentType == "" || u.ContentType == "application/octet-stream" {
+		head, _ := br.Peek(512)
+		u.ContentType = http.DetectContentType(head)
+	}
+	if !up.allowMIME(u.ContentType) {
+		return nil, ErrUploadMIMEType
+	}
+
+	u.reader = &uploadReader{u: u, r: br, max: int64(up.maxSize)}
+	return u, nil
+}
+
+type uploadReader struct {
+	u   *Upload
+	r   io.Reader
+	max int64
+}
+
+func (l *uploadReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.u.Size += int64(n)
+	if l.max > 0 && l.u.Size > l.max {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
+
+func (r *Runtime) spoolUploadFile(u *Upload) error {
+	f, err := os.CreateTemp("", "allino-upload-*")
+	if err != nil {
+		return ErrUploadSpoolFailed.With(err)
+	}
+	r.Defer(func() error {
+		f.Close()
+		return os.Remove(f.Name())
+	})
+
+	if _, err := io.Copy(f, u.reader); err != nil {
+		if err == ErrUploadTooLarge {
+			return err
+		}
+		return ErrUploadSpoolFailed.With(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ErrUploadSpoolFailed.With(err)
+	}
+	u.reader = f
+	return nil
+}
+
+// spoolUploadS3 copies the part to S3 with a multipart upload, keeping only one part in memory.
+func (r *Runtime) spoolUploadS3(u *Upload) error {
+	client := r.S3()
+	bucket := r.config.S3.UploadBucket
+	if client == nil || bucket == "" {
+		return ErrS3UploadNotEnabled
+	}
+
+	ctx := r.Context()
+	key := r.config.S3.UploadPrefix + xid.New().String() + "/" + path.Base(u.Filename)
+
+	created, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(u.ContentType),
+	})
+	if err != nil {
+		return ErrUploadSpoolFailed.With(err)
+	}
+
+	abort := func(err error) error {
+		client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		if err == ErrUploadTooLarge {
+			return err
+		}
+		return ErrUploadSpoolFailed.With(err)
+	}
+
+	var parts []types.CompletedPart
+	buf := make([]byte, uploadS3PartSize)
+	for num := int32(1); ; num++ {
+		n, rerr := io.ReadFull(u.reader, buf)
+		if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+			return abort(rerr)
+		}
+		if n > 0 || num == 1 {
+			out, err := client.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:     aws.String(bucket),
+				Key:        aws.String(key),
+				UploadId:   created.UploadId,
+				PartNumber: aws.Int32(num),
+				Body:       bytes.NewReader(buf[:n]),
+			})
+			if err != nil {
+				return abort(err)
+			}
+			parts = append(parts, types.CompletedPart{
+				ETag:       out.ETag,
+				PartNumber: aws.Int32(num),
+			})
+		}
+		if rerr != nil {
+			break
+		}
+	}
+
+	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(err)
+	}
+
+	u.Bucket = bucket
+	u.Key = key
+	u.reader = nil
+	return nil
+}
@@ -81,44 +81,11 @@ func NewYAMLCustomDecodeOption() []yaml.DecodeOption {
 	opts = append(opts, opt)
 
 	opt = yaml.CustomUnmarshaler(func(ptr *ByteSize, b []byte) error {
-		s := strings.TrimSpace(strings.ToUpper(string(b)))
-		if s == "" {
-			*ptr = 0
-			return nil
-		}
-
-		n, err := strconv.Atoi(s)
-		if err == nil {
-			*ptr = ByteSize(n)
-			return nil
-		}
-
-		// 末尾の単位を判別（B/KB/MB/GB/TB、KiB/MiB... も対応）
-		unitMul := map[string]int64{
-			"B":  1,
-			"KB": 1000, "MB": 1000 * 1000, "GB": 1000 * 1000 * 1000, "TB": 1000 * 1000 * 1000 * 1000,
-			"KIB": 1024, "MIB": 1024 * 1024, "GIB": 1024 * 1024 * 1024, "TIB": 1024 * 1024 * 1024 * 1024,
-		}
-
-		// 数字部分と単位をざっくり分離
-		i := len(s)
-		for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
-			i--
-		}
-		numPart := strings.TrimSpace(s[:i])
-		unitPart := strings.TrimSpace(s[i:])
-		if unitPart == "" {
-			unitPart = "B"
-		}
-		mul, ok := unitMul[unitPart]
-		if !ok {
-			return fmt.Errorf("unknown size unit: %q", unitPart)
-		}
-		v, err := strconv.ParseFloat(numPart, 64)
+		v, err := ParseByteSize(string(b))
 		if err != nil {
-			return fmt.Errorf("invalid size number: %w", err)
+			return err
 		}
-		*ptr = ByteSize(int64(v * float64(mul)))
+		*ptr = v
 		return nil
 	})
 	opts = append(opts, opt)
@@ -174,3 +141,43 @@ func YAMLPathUnmarshal(buf []byte, path string, config any) (err error) {
 
 	return
 }
+
+// ParseByteSize parses "1024", "10MB", "1.5GiB" etc.
+func ParseByteSize(str string) (ByteSize, error) {
+	s := strings.TrimSpace(strings.ToUpper(str))
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err == nil {
+		return ByteSize(n), nil
+	}
+
+	// 末尾の単位を判別（B/KB/MB/GB/TB、KiB/MiB... も対応）
+	unitMul := map[string]int64{
+		"B":  1,
+		"KB": 1000, "MB": 1000 * 1000, "GB": 1000 * 1000 * 1000, "TB": 1000 * 1000 * 1000 * 1000,
+		"KIB": 1024, "MIB": 1024 * 1024, "GIB": 1024 * 1024 * 1024, "TIB": 1024 * 1024 * 1024 * 1024,
+	}
+
+	// 数字部分と単位をざっくり分離
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	numPart := strings.TrimSpace(s[:i])
+	unitPart := strings.TrimSpace(s[i:])
+	if unitPart == "" {
+		unitPart = "B"
+	}
+	mul, ok := unitMul[unitPart]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit: %q", unitPart)
+	}
+	v, err := strconv.ParseFloat(numPart, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size number: %w", err)
+	}
+	return ByteSize(int64(v * float64(mul))), nil
+}
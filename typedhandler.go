@@ -71,24 +71,45 @@ func getPriority(segment string) int {
 }
 
 func (s *Server) RegisterAllFunction() {
-	list := make([]*idxhandler, len(FunctionList))
+	// CLI commands share one server, skip functions that are already registered.
+	registered := make(map[Function]bool, len(s.FunctionCache))
+	for _, h := range s.FunctionCache {
+		registered[h] = true
+	}
+
+	list := make([]*idxhandler, 0, len(FunctionList))
 	for i, h := range FunctionList {
-		list[i] = &idxhandler{i, h}
+		if !registered[h] {
+			list = append(list, &idxhandler{i, h})
+		}
 	}
 
-	sort.Slice(list, func(i, j int) bool {
+	// stable: ambiguous routes keep the declaration order.
+	sort.SliceStable(list, func(i, j int) bool {
 		return comparePaths(list[i].h.Options().Path, list[j].h.Options().Path)
 	})
 
 	for _, l := range list {
 		s.TypedHandle(l.h)
 	}
+
+	for _, c := range s.CheckRoutes() {
+		s.Logger.Warn("route conflict",
+			zap.String("kind", c.Kind),
+			zap.String("method", c.Method),
+			zap.String("path", c.Path),
+			zap.String("other", c.Other),
+		)
+	}
 }
 
 var ErrServerError = NewCodeError(500, "server_error", "server error")
 
 func NewFunction[T, U any, E error](option Option, handlefunc func(r *Runtime, input T) (output U, err E)) *GenericFunction[T, U, E] {
 	options := &option
+	if options.Group != nil {
+		options.Group.apply(options)
+	}
 	if options.Package == "" {
 		options.Package, _ = findExternalCaller([]string{"github.com/wh-kuromai/allino"})
 	}
@@ -137,6 +158,14 @@ func NewFunction[T, U any, E error](option Option, handlefunc func(r *Runtime, i
 	}
 	options.inputType = reflect.TypeOf(t).Elem()
 	options.inputReflectPlan = buildPlan(option.inputType)
+	if rp := options.inputReflectPlan; rp != nil && rp.uploads && rp.fileHeaders {
+		// a streamed body is read once, it cannot also be parsed for *multipart.FileHeader.
+		panic("upload field error: " + rp.typ.Name() + " mixes *multipart.FileHeader and streamed upload fields")
+	}
+	options.responseFields, err = buildResponseFields(reflect.TypeOf(u).Elem())
+	if err != nil {
+		panic("response field error: " + err.Error())
+	}
 
 	if reflect.TypeOf(k).Elem() == reflect.TypeOf((*error)(nil)).Elem() {
 		options.eiserror = true
@@ -190,6 +219,8 @@ func NewFunction[T, U any, E error](option Option, handlefunc func(r *Runtime, i
 			var err error
 			// instantiate param if it is a pointer type
 			if IsAny[T]() {
+			} else if options.MaxBodySize > 0 && r.requestBodySize() > int(options.MaxBodySize) {
+				err = ErrBodyTooLarge
 			} else if newParamFn != nil {
 				param = newParamFn()
 				err = r.getAll(param, options.inputReflectPlan)
@@ -214,6 +245,19 @@ func NewFunction[T, U any, E error](option Option, handlefunc func(r *Runtime, i
 					}
 				}
 
+				// auth and ACL are checked before a stream sends its status with the headers.
+				if err == nil && !consumed {
+					err = rw.enforce(r, param)
+				}
+
+				if err == nil && !consumed && options.streaming {
+					r.serveEventStream(options, func(r *Runtime) error {
+						_, err := rw.call_internal(r, param, false)
+						return err
+					})
+					return
+				}
+
 				if err == nil && !consumed {
 					resp, err = rw.call_internal(r, param, false)
 				}
@@ -287,6 +331,8 @@ func NewFunction[T, U any, E error](option Option, handlefunc func(r *Runtime, i
 	}
 	options.hasSelfDiscovery = hasSelfDiscovery(reflect.TypeOf(t).Elem())
 	options.invoker = rw.invokeFunctionJSON
+	options.caller = rw.callFunctionJSON
+	options.jobResult = rw.jobResultJSON
 
 	FunctionList = append(FunctionList, rw)
 	return rw
@@ -381,13 +427,45 @@ func (rw *GenericFunction[T, U, E]) Call(r *Runtime, input T) (output U, err err
 	return
 }
 
+// enforce checks Option.Auth and the ACL of the function for the caller of r.
+// It runs at the HTTP, MCP and WebSocket entry points, server side calls are trusted.
+func (rw *GenericFunction[T, U, E]) enforce(r *Runtime, input T) error {
+	if err := r.enforceAuth(rw.options); err != nil {
+		return err
+	}
+	return r.enforceACL(rw.options, input)
+}
+
+// call_internal runs the middleware chain around the dispatch, so cached, deduplicated and
+// queued calls pass through it on the calling side. Job workers run the handler without it.
 func (rw *GenericFunction[T, U, E]) call_internal(r *Runtime, input T, fromcall bool) (output U, err error) {
-	//var zeroU U
-	if err := r.enforceACL(rw.options, input); err != nil {
-		var zeroU U
-		return zeroU, err
+	return rw.middleware(r, input, func() (U, error) {
+		return rw.call_dispatch(r, input, fromcall)
+	})
+}
+
+// middleware runs fn through the middleware chain of the function. An output of another
+// type than U is a server error.
+func (rw *GenericFunction[T, U, E]) middleware(r *Runtime, input T, fn func() (U, error)) (output U, err error) {
+	chain := middlewareChain(r, rw.options)
+	if len(chain) == 0 {
+		return fn()
+	}
+	out, err := runMiddleware(r, rw.options, chain, input, func() (any, error) {
+		return fn()
+	})
+	if out == nil {
+		return output, err
 	}
+	output, ok := out.(U)
+	if !ok {
+		return output, ErrServerError.With(fmt.Errorf("middleware returned %T, expected %s", out, reflect.TypeFor[U]()))
+	}
+	return output, err
+}
 
+func (rw *GenericFunction[T, U, E]) call_dispatch(r *Runtime, input T, fromcall bool) (output U, err error) {
+	//var zeroU U
 	if rw.options.Session.Type != "" {
 		return rw.call_session(r, input, fromcall)
 	}
@@ -442,7 +520,9 @@ func (rw *GenericFunction[T, U, E]) HandleRequest(r *Runtime) {
 func (rw *GenericFunction[T, U, E]) Handlefunc(r *Runtime, input any) (output any, err error) {
 	var inT T
 	inT, _ = input.(T)
-	return rw.handlefunc(r, inT)
+	return rw.middleware(r, inT, func() (U, error) {
+		return rw.handlefunc(r, inT)
+	})
 }
 
 var contentTypeHandlerMap map[string]*contentTypeHandler
@@ -464,7 +544,7 @@ func init() {
 	contentTypeHandlerMap = make(map[string]*contentTypeHandler)
 	contentTypeHandlerMap[HTML] = &contentTypeHandler{
 		responseHandler: func(r *Runtime, options *Option, output any) {
-			r.fiber.Status(options.ResponseStatusCode)
+			r.fiber.Status(r.applyResponseFields(options, output))
 			switch v := output.(type) {
 			case []byte:
 				r.fiber.Write(v)
@@ -506,6 +586,7 @@ func init() {
 
 	contentTypeHandlerMap[JSON] = &contentTypeHandler{
 		responseHandler: func(r *Runtime, options *Option, output any) {
+			status := r.applyResponseFields(options, output)
 			if !options.NoWrapJSON {
 				output = &APIResponse[any]{output}
 			}
@@ -515,7 +596,7 @@ func init() {
 				return
 			}
 
-			r.fiber.Status(options.ResponseStatusCode)
+			r.fiber.Status(status)
 			_ = r.fiber.Send(buf)
 		},
 		errorHandler: func(r *Runtime, options *Option, err error) {
@@ -527,6 +608,21 @@ func init() {
 			r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, err)
 		},
 	}
+
+	contentTypeHandlerMap[EventStream] = &contentTypeHandler{
+		responseHandler: func(r *Runtime, options *Option, output any) {
+			buf, err := json.Marshal(output)
+			if err != nil {
+				eventStreamErrorHandler(r, options, err)
+				return
+			}
+			r.fiber.Status(options.ResponseStatusCode)
+			r.serveEventStream(options, func(r *Runtime) error {
+				return r.memo.streamSink(json.RawMessage(buf))
+			})
+		},
+		errorHandler: eventStreamErrorHandler,
+	}
 }
 
 func findExternalCaller(excludePrefixes []string) (string, string) {
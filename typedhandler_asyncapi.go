@@ -0,0 +1,140 @@
+package allino
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/wh-kuromai/jsonino"
+)
+
+type AsyncAPI struct {
+	AsyncAPI string                   `json:"asyncapi"`
+	Info     map[string]interface{}   `json:"info"`
+	Channels map[string]*AsyncChannel `json:"channels"`
+}
+
+type AsyncChannel struct {
+	Description string                     `json:"description,omitempty"`
+	Parameters  map[string]*AsyncParameter `json:"parameters,omitempty"`
+	Publish     *AsyncOperation            `json:"publish,omitempty"`   // client -> server
+	Subscribe   *AsyncOperation            `json:"subscribe,omitempty"` // server -> client
+}
+
+type AsyncParameter struct {
+	Schema any `json:"schema,omitempty"`
+}
+
+type AsyncOperation struct {
+	OperationID string        `json:"operationId,omitempty"`
+	Summary     string        `json:"summary,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Message     *AsyncMessage `json:"message,omitempty"`
+}
+
+type AsyncMessage struct {
+	Name        string          `json:"name,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	Payload     any             `json:"payload,omitempty"`
+	OneOf       []*AsyncMessage `json:"oneOf,omitempty"`
+}
+
+// GenerateAsyncAPI describes the message schemas of every function created by NewWebsocketFunction.
+func (r *Server) GenerateAsyncAPI() *AsyncAPI {
+	asyncapi := &AsyncAPI{
+		AsyncAPI: "2.6.0",
+		Info: map[string]interface{}{
+			"title":   r.Config.AppName,
+			"version": r.Config.Version,
+		},
+		Channels: make(map[string]*AsyncChannel),
+	}
+
+	for _, h := range r.FunctionCache {
+		opt := h.Options()
+		if !opt.websocket {
+			continue
+		}
+		channel, _ := templatePath(opt.RoutePath())
+		asyncapi.Channels[channel] = generateChannelFromOptions(opt)
+	}
+	return asyncapi
+}
+
+func generateChannelFromOptions(opt *Option) *AsyncChannel {
+	ch := &AsyncChannel{
+		Description: opt.Description,
+	}
+
+	inputType := opt.inputType
+	if inputType.Kind() == reflect.Ptr {
+		inputType = inputType.Elem()
+	}
+	if inputType.Kind() == reflect.Struct {
+		params, _, _ := parseParametersAndFormData(inputType, newSchemaRegistry())
+		for _, p := range params {
+			if p.In != "path" {
+				continue
+			}
+			if ch.Parameters == nil {
+				ch.Parameters = make(map[string]*AsyncParameter)
+			}
+			ch.Parameters[p.Name] = &AsyncParameter{Schema: p.Schema}
+		}
+	}
+
+	inschema, _ := jsonino.SchemaFrom(opt.inputType)
+	ch.Publish = &AsyncOperation{
+		OperationID: opt.Name,
+		Summary:     opt.Summary,
+		Message: &AsyncMessage{
+			Name:        "input",
+			ContentType: JSON,
+			Payload:     inschema,
+		},
+	}
+
+	ch.Subscribe = &AsyncOperation{
+		Message: &AsyncMessage{
+			OneOf: []*AsyncMessage{
+				{Name: "output", ContentType: JSON, Payload: asyncPayload(opt, opt.outputType, "data")},
+				{Name: "error", ContentType: JSON, Payload: asyncPayload(opt, opt.errorType, "error")},
+			},
+		},
+	}
+
+	return ch
+}
+
+var tAPIError = reflect.TypeOf(Error{})
+
+// asyncPayload is the schema of a frame sent by serveWebsocket: the value in {"data": ...} or
+// {"error": ...} unless NoWrapJSON. Errors without a JSON form are sent as Error.
+func asyncPayload(opt *Option, t reflect.Type, key string) any {
+	if t == nil {
+		return nil
+	}
+	t = unwrapAPIType(t)
+	if t.Kind() == reflect.Interface {
+		t = tAPIError
+	}
+	schema, err := jsonino.SchemaFrom(t)
+	if err != nil {
+		return nil
+	}
+	if opt.NoWrapJSON {
+		return schema
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{key: schema},
+	}
+}
+
+// unwrapAPIType returns T of APIResponse[T] and APIError[T], the types of JSON functions.
+func unwrapAPIType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Struct && t.PkgPath() == tAPIError.PkgPath() &&
+		(strings.HasPrefix(t.Name(), "APIResponse[") || strings.HasPrefix(t.Name(), "APIError[")) {
+		return t.Field(0).Type
+	}
+	return t
+}
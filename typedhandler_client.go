@@ -0,0 +1,471 @@
+package allino
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed typedhandler_client_request.go
+var clientRequestSource string
+
+var tAPIResponse = reflect.TypeOf(APIResponse[any]{})
+
+// GenerateClient returns the source of a Go package that calls the registered functions over HTTP.
+// Each JSON function becomes one method of Client, input fields are sent with the same
+// path/query/form/post/cookie/header tags the server binds them from.
+// Streaming and WebSocket functions are skipped.
+func (s *Server) GenerateClient(pkg string) ([]byte, error) {
+	if pkg == "" {
+		pkg = "client"
+	}
+
+	g := &clientGenerator{
+		names:   map[reflect.Type]string{},
+		used:    map[string]bool{"Client": true, "Error": true, "New": true},
+		imports: map[string]bool{},
+		inline:  map[reflect.Type]bool{},
+	}
+
+	methods := map[string]bool{"BaseURL": true, "Token": true, "Header": true, "HTTPClient": true, "do": true}
+	for _, h := range clientFunctions(s) {
+		g.method(clientMethodName(methods, h.Options()), h.Options())
+	}
+
+	return g.source(pkg)
+}
+
+// clientFunctions returns the routed functions a client can call, sorted by path and method.
+func clientFunctions(s *Server) []Function {
+	fns := make([]Function, 0, len(s.FunctionCache))
+	for _, h := range s.FunctionCache {
+		opt := h.Options()
+		if opt.Path == "" || opt.streaming || opt.websocket || opt.inputType == nil {
+			continue
+		}
+		if opt.ContentType != JSON && !isRawClientOutput(clientOutputType(opt)) {
+			continue
+		}
+		fns = append(fns, h)
+	}
+	sort.SliceStable(fns, func(i, j int) bool {
+		a, b := fns[i].Options(), fns[j].Options()
+		if a.RoutePath() != b.RoutePath() {
+			return a.RoutePath() < b.RoutePath()
+		}
+		return a.Method < b.Method
+	})
+	return fns
+}
+
+// clientMethodName returns the exported name of the operationId, unique within used.
+func clientMethodName(used map[string]bool, opt *Option) string {
+	name := clientIdent(operationID(opt, opt.Method))
+	base := name
+	for i := 2; used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+type clientGenerator struct {
+	names   map[reflect.Type]string
+	used    map[string]bool
+	decls   []string
+	imports map[string]bool
+	inline  map[reflect.Type]bool
+	methods bytes.Buffer
+}
+
+// clientOutputType unwraps APIResponse[U], the generated methods return U.
+func clientOutputType(opt *Option) reflect.Type {
+	t := opt.outputType
+	if t != nil && t.Kind() == reflect.Struct && t.PkgPath() == tAPIResponse.PkgPath() &&
+		strings.HasPrefix(t.Name(), "APIResponse[") {
+		return t.Field(0).Type
+	}
+	return t
+}
+
+func isRawClientOutput(t reflect.Type) bool {
+	return t != nil && (t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8))
+}
+
+func (g *clientGenerator) method(name string, opt *Option) {
+	in := opt.inputType
+	out := clientOutputType(opt)
+	wrapped := opt.ContentType == JSON && !opt.NoWrapJSON
+	p, _ := templatePath(opt.RoutePath())
+
+	w := &g.methods
+	if opt.Summary != "" {
+		fmt.Fprintf(w, "// %s %s\n", name, opt.Summary)
+	} else {
+		fmt.Fprintf(w, "// %s calls %s %s.\n", name, strings.ToUpper(opt.Method), opt.RoutePath())
+	}
+
+	outExpr := "any"
+	if out != nil {
+		if e := g.typeExpr(out); e != "" {
+			outExpr = e
+		}
+	}
+
+	inExpr := ""
+	if in.Kind() != reflect.Interface {
+		inExpr = g.typeExpr(in)
+	}
+	if inExpr == "" {
+		fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context) (%s, error) {\n", name, outExpr)
+	} else {
+		fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context, in %s) (%s, error) {\n", name, inExpr, outExpr)
+	}
+	fmt.Fprintf(w, "\treq := newClientRequest(%q, %q)\n", strings.ToUpper(opt.Method), p)
+	if inExpr != "" {
+		if in.Kind() == reflect.Ptr {
+			fmt.Fprintf(w, "\tif in == nil {\n\t\tin = new(%s)\n\t}\n", strings.TrimPrefix(inExpr, "*"))
+		}
+		g.bindings(w, in, "in")
+	}
+	fmt.Fprintf(w, "\tvar out %s\n", outExpr)
+	fmt.Fprintf(w, "\terr := c.do(ctx, req, %t, &out)\n", wrapped)
+	fmt.Fprint(w, "\treturn out, err\n}\n\n")
+}
+
+// bindings writes one request call per tagged field, the first tag found wins as in getByStructField.
+func (g *clientGenerator) bindings(w *bytes.Buffer, t reflect.Type, expr string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if g.typeExpr(sf.Type) == "" {
+			continue
+		}
+		fieldExpr := expr + "." + g.fieldName(sf)
+
+		tagged := false
+		for _, k := range remoteTagOrder {
+			v, ok := sf.Tag.Lookup(k.String())
+			if !ok {
+				continue
+			}
+			if v == "" {
+				v = sf.Name
+			}
+			tagged = true
+			switch k {
+			case tagPath:
+				fmt.Fprintf(w, "\treq.setPath(%q, %s)\n", pathParamName(v), fieldExpr)
+			case tagQuery:
+				fmt.Fprintf(w, "\treq.addQuery(%q, %s)\n", v, fieldExpr)
+			case tagForm:
+				fmt.Fprintf(w, "\treq.addForm(%q, %s)\n", v, fieldExpr)
+			case tagPost:
+				fmt.Fprintf(w, "\treq.setBody(%q, %s)\n", v, fieldExpr)
+			case tagCookie:
+				fmt.Fprintf(w, "\treq.setCookie(%q, %s)\n", v, fieldExpr)
+			case tagHeader:
+				fmt.Fprintf(w, "\treq.setHeader(%q, %s)\n", v, fieldExpr)
+			}
+			break
+		}
+
+		if !tagged && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			g.bindings(w, sf.Type, fieldExpr)
+		}
+	}
+}
+
+// fieldName is the name of sf in the generated struct, embedded fields are named after their generated type.
+func (g *clientGenerator) fieldName(sf reflect.StructField) string {
+	if !sf.Anonymous {
+		return sf.Name
+	}
+	e := strings.TrimPrefix(g.typeExpr(sf.Type), "*")
+	if i := strings.LastIndex(e, "."); i >= 0 {
+		e = e[i+1:]
+	}
+	return e
+}
+
+// typeExpr returns the Go expression of t in the generated package, "" for unsupported types.
+// Named types of other modules are re-declared, standard library types are imported.
+func (g *clientGenerator) typeExpr(t reflect.Type) string {
+	switch {
+	case t == tIOReader || t == tUploadPtr:
+		g.imports["io"] = true
+		return "io.Reader"
+	case t == tFileHeader || t == reflect.PointerTo(tFileHeader):
+		return ""
+	}
+
+	if t.Kind() == reflect.Ptr {
+		e := g.typeExpr(t.Elem())
+		if e == "" || e == "any" {
+			return e
+		}
+		return "*" + e
+	}
+
+	if t.Name() != "" && t.PkgPath() != "" {
+		if isStdPackage(t.PkgPath()) {
+			g.imports[t.PkgPath()] = true
+			return path.Base(t.PkgPath()) + "." + t.Name()
+		}
+		if !strings.Contains(t.Name(), "[") {
+			return g.named(t)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Kind().String()
+	case reflect.Interface:
+		return "any"
+	case reflect.Slice:
+		if e := g.typeExpr(t.Elem()); e != "" {
+			return "[]" + e
+		}
+	case reflect.Array:
+		if e := g.typeExpr(t.Elem()); e != "" {
+			return fmt.Sprintf("[%d]%s", t.Len(), e)
+		}
+	case reflect.Map:
+		k, e := g.typeExpr(t.Key()), g.typeExpr(t.Elem())
+		if k != "" && e != "" {
+			return "map[" + k + "]" + e
+		}
+	case reflect.Struct:
+		// generic instances and anonymous structs are written inline
+		if g.inline[t] {
+			return "any"
+		}
+		g.inline[t] = true
+		defer delete(g.inline, t)
+		return g.structExpr(t)
+	}
+	return ""
+}
+
+// named declares a named type once and returns its (collision free) name.
+func (g *clientGenerator) named(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := clientIdent(t.Name())
+	if g.used[name] {
+		name = clientIdent(path.Base(t.PkgPath()) + "_" + t.Name())
+		base := name
+		for i := 2; g.used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+	}
+	g.used[name] = true
+	g.names[t] = name
+
+	var underlying string
+	switch {
+	case t.Implements(tTextMarshaler) || reflect.PointerTo(t).Implements(tTextMarshaler):
+		// the wire format is the text form
+		underlying = "string"
+	case t.Kind() == reflect.Struct:
+		underlying = g.structExpr(t)
+	case underlyingType(t) != nil:
+		underlying = g.typeExpr(underlyingType(t))
+	case t.Kind() == reflect.String || t.Kind() >= reflect.Bool && t.Kind() <= reflect.Float64:
+		underlying = t.Kind().String()
+	}
+	if underlying == "" {
+		underlying = "any"
+	}
+	g.decls = append(g.decls, fmt.Sprintf("type %s %s\n", name, underlying))
+	return name
+}
+
+func (g *clientGenerator) structExpr(t reflect.Type) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		e := g.typeExpr(sf.Type)
+		if e == "" {
+			continue
+		}
+		if sf.Anonymous {
+			b.WriteString(e)
+		} else {
+			b.WriteString(sf.Name + " " + e)
+		}
+		if sf.Tag != "" {
+			tag := string(sf.Tag)
+			if strings.Contains(tag, "`") {
+				tag = strconv.Quote(tag)
+			} else {
+				tag = "`" + tag + "`"
+			}
+			b.WriteString(" " + tag)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// underlyingType returns the unnamed type of a named non-struct type, e.g. string for `type Status string`.
+func underlyingType(t reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Slice:
+		return reflect.SliceOf(t.Elem())
+	case reflect.Array:
+		return reflect.ArrayOf(t.Len(), t.Elem())
+	case reflect.Map:
+		return reflect.MapOf(t.Key(), t.Elem())
+	case reflect.Ptr:
+		return reflect.PointerTo(t.Elem())
+	case reflect.Interface:
+		return reflect.TypeOf((*any)(nil)).Elem()
+	}
+	return nil
+}
+
+func isStdPackage(pkg string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".")
+}
+
+// clientIdent turns an operationId or type name into an exported Go identifier.
+func clientIdent(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	id := b.String()
+	if id == "" || !unicode.IsLetter(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
+
+const clientTemplate = `
+// Client calls the functions of an allino server.
+type Client struct {
+	BaseURL    string
+	Token      string       // optional: sent as "Authorization: Bearer"
+	Header     http.Header  // optional: added to every request
+	HTTPClient *http.Client // optional: defaults to http.DefaultClient
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// Error is the error response of the server.
+type Error struct {
+	Status int    ` + "`json:\"-\"`" + `
+	Code   string ` + "`json:\"code,omitempty\"`" + `
+	Msg    string ` + "`json:\"msg,omitempty\"`" + `
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (c *Client) do(ctx context.Context, req *clientRequest, wrapped bool, out any) error {
+	httpreq, err := req.build(ctx, c.BaseURL, c.Token)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.Header {
+		httpreq.Header[k] = v
+	}
+	return clientDo(c.HTTPClient, httpreq, wrapped, out, func(status int, code, msg string) error {
+		return &Error{Status: status, Code: code, Msg: msg}
+	})
+}
+
+`
+
+func (g *clientGenerator) source(pkg string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client_request.go", clientRequestSource, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	imports := map[string]bool{"context": true, "net/http": true}
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		imports[p] = true
+	}
+	for p := range g.imports {
+		imports[p] = true
+	}
+
+	// the helper body starts after its import block
+	var helperStart token.Pos
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			helperStart = gd.End()
+		}
+	}
+	helper := clientRequestSource[fset.Position(helperStart).Offset:]
+
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by allino client. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", pkg)
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\t%q\n", p)
+	}
+	b.WriteString(")\n")
+	b.WriteString(clientTemplate)
+
+	decls := append([]string(nil), g.decls...)
+	sort.Strings(decls)
+	for _, d := range decls {
+		b.WriteString(d + "\n")
+	}
+	b.Write(g.methods.Bytes())
+	b.WriteString(helper)
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,324 @@
+package allino
+
+// clientRequest builds an HTTP request from input fields the same way allino binds them
+// (path, query, form, post, cookie and header tags).
+// It is used by RemoteFunction and copied as-is into clients generated by the `client` command,
+// so this file must only use the standard library.
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var clientPathParamRe = regexp.MustCompile(`/?\{[^}/]+\}`)
+
+type clientRequest struct {
+	method  string
+	path    string // URI template, /users/{id}
+	query   url.Values
+	form    url.Values
+	files   []*clientFile
+	header  http.Header
+	cookies []*http.Cookie
+	body    []byte
+	ctype   string
+	err     error
+}
+
+type clientFile struct {
+	field string
+	r     io.Reader
+}
+
+func newClientRequest(method, path string) *clientRequest {
+	return &clientRequest{
+		method: method,
+		path:   path,
+		query:  url.Values{},
+		form:   url.Values{},
+		header: http.Header{},
+	}
+}
+
+func (q *clientRequest) setPath(name string, v any) {
+	if s, ok := clientValueString(v); ok {
+		q.path = strings.Replace(q.path, "{"+name+"}", url.PathEscape(s), 1)
+	}
+}
+
+func (q *clientRequest) addQuery(name string, v any) {
+	if s, ok := clientValueString(v); ok {
+		q.query.Add(name, s)
+	}
+}
+
+// addForm adds a form value, io.Reader values are sent as multipart file parts.
+func (q *clientRequest) addForm(name string, v any) {
+	if r, ok := v.(io.Reader); ok {
+		if !clientIsNil(v) {
+			q.files = append(q.files, &clientFile{field: name, r: r})
+		}
+		return
+	}
+	if s, ok := clientValueString(v); ok {
+		q.form.Add(name, s)
+	}
+}
+
+func (q *clientRequest) setCookie(name string, v any) {
+	if s, ok := clientValueString(v); ok {
+		q.cookies = append(q.cookies, &http.Cookie{Name: name, Value: s})
+	}
+}
+
+func (q *clientRequest) setHeader(name string, v any) {
+	if s, ok := clientValueString(v); ok {
+		q.header.Set(name, s)
+	}
+}
+
+// setBody encodes a post:"format" field.
+func (q *clientRequest) setBody(format string, v any) {
+	if clientIsNil(v) {
+		return
+	}
+
+	var err error
+	switch format {
+	case "raw":
+		switch b := v.(type) {
+		case []byte:
+			q.body = b
+		case string:
+			q.body = []byte(b)
+		default:
+			err = fmt.Errorf("post:\"raw\" needs []byte, got %T", v)
+		}
+		q.ctype = "application/octet-stream"
+	case "xml":
+		q.body, err = xml.Marshal(v)
+		q.ctype = "application/xml"
+	case "yaml":
+		// JSON is valid YAML.
+		q.body, err = json.Marshal(v)
+		q.ctype = "application/yaml"
+	case "msgpack":
+		err = errors.New("msgpack request bodies are not supported by the client")
+	default:
+		q.body, err = json.Marshal(v)
+		q.ctype = "application/json"
+	}
+	if err != nil && q.err == nil {
+		q.err = err
+	}
+}
+
+func (q *clientRequest) build(ctx context.Context, baseURL, token string) (*http.Request, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	// drop unset optional parameters
+	u := strings.TrimRight(baseURL, "/") + clientPathParamRe.ReplaceAllString(q.path, "")
+	if len(q.query) > 0 {
+		u += "?" + q.query.Encode()
+	}
+
+	var body io.Reader
+	ctype := q.ctype
+	switch {
+	case q.body != nil:
+		body = bytes.NewReader(q.body)
+	case len(q.files) > 0:
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		ctype = mw.FormDataContentType()
+		go func() {
+			pw.CloseWithError(q.writeMultipart(mw))
+		}()
+		body = pr
+	case len(q.form) > 0:
+		body = strings.NewReader(q.form.Encode())
+		ctype = "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, q.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range q.header {
+		req.Header[k] = v
+	}
+	for _, c := range q.cookies {
+		req.AddCookie(c)
+	}
+	if ctype != "" {
+		req.Header.Set("Content-Type", ctype)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// writeMultipart writes form values first, files last (the server streams the last file part).
+func (q *clientRequest) writeMultipart(mw *multipart.Writer) error {
+	for k, vs := range q.form {
+		for _, v := range vs {
+			if err := mw.WriteField(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range q.files {
+		w, err := mw.CreateFormFile(f.field, f.field)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, f.r); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// clientDo sends the request and decodes the response into out.
+// wrapped unpacks {"data":...}, otherwise *[]byte and *string outputs receive the raw body.
+func clientDo(client *http.Client, req *http.Request, wrapped bool, out any,
+	newError func(status int, code, msg string) error) error {
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Code string `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		var w struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal(raw, &w) == nil && len(w.Error) > 0 {
+			json.Unmarshal(w.Error, &e)
+		} else {
+			json.Unmarshal(raw, &e)
+		}
+		if e.Msg == "" {
+			e.Msg = http.StatusText(resp.StatusCode)
+		}
+		return newError(resp.StatusCode, e.Code, e.Msg)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if !wrapped {
+		switch o := out.(type) {
+		case *[]byte:
+			*o = raw
+			return nil
+		case *string:
+			*o = string(raw)
+			return nil
+		}
+	}
+
+	if len(raw) == 0 {
+		return nil
+	}
+	if wrapped {
+		var w struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &w); err != nil {
+			return err
+		}
+		raw = w.Data
+		if len(raw) == 0 {
+			return nil
+		}
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func clientIsNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// clientValueString formats a path/query/form/header value, zero values are omitted
+// so that default:"..." tags apply on the server.
+func clientValueString(v any) (string, bool) {
+	if clientIsNil(v) {
+		return "", false
+	}
+	rv := reflect.ValueOf(v)
+	explicit := false
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+		explicit = true
+	}
+	if !explicit && rv.IsZero() {
+		return "", false
+	}
+
+	switch x := rv.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano), true
+	case []byte:
+		return string(x), true
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		return string(b), err == nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true
+	}
+	return fmt.Sprint(rv.Interface()), true
+}
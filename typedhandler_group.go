@@ -0,0 +1,125 @@
+package allino
+
+import "strings"
+
+// RouteGroup shares a path prefix, Option defaults and request handlers among functions
+// that set Option.Group.
+//
+//	var Admin = allino.Group("/admin", allino.Option{ACLResource: "admin", CORS: true})
+//
+//	var ListUsers = allino.NewFunction(allino.Option{Group: Admin, Path: "/users"}, listUsers)
+type RouteGroup struct {
+	Name string // OpenAPI tag and route output heading, defaults to the prefix without slashes
+
+	prefix   string
+	option   Option
+	parent   *RouteGroup
+	handlers []func(r *Runtime, input any) (consumed bool, err error)
+}
+
+// Group creates a group whose functions are mounted under prefix. The shared fields of option
+// (see apply) are the defaults of its functions and option.RequestHandler runs before theirs.
+func Group(prefix string, option Option) *RouteGroup {
+	return newRouteGroup(nil, prefix, option)
+}
+
+// Group creates a nested group, its defaults take precedence over the parent's.
+func (g *RouteGroup) Group(prefix string, option Option) *RouteGroup {
+	return newRouteGroup(g, prefix, option)
+}
+
+func newRouteGroup(parent *RouteGroup, prefix string, option Option) *RouteGroup {
+	prefix = "/" + strings.Trim(prefix, "/")
+	if parent != nil {
+		prefix = strings.TrimSuffix(parent.prefix, "/") + prefix
+	}
+	option.Group = nil
+	return &RouteGroup{
+		Name:   strings.Trim(prefix, "/"),
+		prefix: prefix,
+		option: option,
+		parent: parent,
+	}
+}
+
+// Prefix returns the path prefix including the prefixes of parent groups.
+func (g *RouteGroup) Prefix() string {
+	return g.prefix
+}
+
+// Use adds request handlers run before the RequestHandler of the member functions, in order,
+// until one consumes the request or returns an error. Call Use before serving.
+func (g *RouteGroup) Use(handlers ...func(r *Runtime, input any) (consumed bool, err error)) *RouteGroup {
+	g.handlers = append(g.handlers, handlers...)
+	return g
+}
+
+// requestHandlers returns the handlers of the parent groups first.
+func (g *RouteGroup) requestHandlers() []func(r *Runtime, input any) (bool, error) {
+	var hs []func(r *Runtime, input any) (bool, error)
+	if g.parent != nil {
+		hs = g.parent.requestHandlers()
+	}
+	if g.option.RequestHandler != nil {
+		hs = append(hs, g.option.RequestHandler)
+	}
+	return append(hs, g.handlers...)
+}
+
+// apply mounts opt under the group prefix and fills the shared options opt leaves empty:
+// Method, ContentType, CORS, CORSCustomHeader, Auth, ACLResource, ACLAction, ResponseHandler
+// and ErrorHandler. The request handlers and middleware of the groups run before opt's own.
+// Other fields, e.g. Job, MCP or APIVersion, are not inherited.
+func (g *RouteGroup) apply(opt *Option) {
+	if opt.Path != "" {
+		opt.Path = strings.TrimSuffix(g.prefix, "/") + opt.Path
+	}
+
+	for grp := g; grp != nil; grp = grp.parent {
+		shared := &grp.option
+		inheritOption(&opt.Method, shared.Method)
+		inheritOption(&opt.ContentType, shared.ContentType)
+		inheritOption(&opt.CORS, shared.CORS)
+		inheritOption(&opt.Auth, shared.Auth)
+		// the action belongs to the resource, a member with its own resource keeps its action.
+		if opt.ACLResource == "" && shared.ACLResource != "" {
+			opt.ACLResource = shared.ACLResource
+			inheritOption(&opt.ACLAction, shared.ACLAction)
+		}
+		if opt.CORSCustomHeader == nil {
+			opt.CORSCustomHeader = shared.CORSCustomHeader
+		}
+		if opt.ResponseHandler == nil {
+			opt.ResponseHandler = shared.ResponseHandler
+		}
+		if opt.ErrorHandler == nil {
+			opt.ErrorHandler = shared.ErrorHandler
+		}
+	}
+
+	var mws []Middleware
+	for grp := g; grp != nil; grp = grp.parent {
+		mws = append(append([]Middleware{}, grp.option.Middleware...), mws...)
+	}
+	opt.Middleware = append(mws, opt.Middleware...)
+
+	own := opt.RequestHandler
+	opt.RequestHandler = func(r *Runtime, input any) (bool, error) {
+		for _, h := range g.requestHandlers() {
+			if consumed, err := h(r, input); consumed || err != nil {
+				return consumed, err
+			}
+		}
+		if own != nil {
+			return own(r, input)
+		}
+		return false, nil
+	}
+}
+
+func inheritOption[V comparable](field *V, shared V) {
+	var zero V
+	if *field == zero {
+		*field = shared
+	}
+}
@@ -3,6 +3,7 @@ package allino
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"reflect"
 	"strings"
 	"time"
@@ -448,6 +449,82 @@ func (rw *GenericFunction[T, U, E]) invokeFunctionJSON(r *Runtime, handler, vers
 	return key, outJSON, errJSON, syserr
 }
 
+// called from MCP: like Function.Call, auth, ACL, job modes and sessions apply.
+// A pending async job is returned as *JobPendingError.
+func (rw *GenericFunction[T, U, E]) callFunctionJSON(r *Runtime, injson []byte, infunc func(input any) error) (outputz []byte, errjsonz []byte, syserr error) {
+	input, err := rw.tpool.New(func(a any) error {
+		return json.Unmarshal(injson, a)
+	})
+	if err != nil {
+		return nil, nil, ErrJobInputDecodeFailed.With(err)
+	}
+
+	if rw.options.hasSelfDiscovery {
+		if ferr := fillSelfDiscovery(input); ferr != nil {
+			return nil, nil, ErrJobInputDecodeFailed.With(ferr)
+		}
+	}
+
+	if infunc != nil {
+		if err := infunc(input); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := rw.enforce(r, input); err != nil {
+		return nil, nil, err
+	}
+
+	output, err := rw.call_internal(r, input, true)
+	var pending *JobPendingError
+	if errors.As(err, &pending) {
+		return nil, nil, pending
+	}
+	return marshalOutputSet[U, E](output, err)
+}
+
+// called from MCP to poll a job handle returned by callFunctionJSON.
+// Job ids are derived from the input, so the caller must pass auth and ACL for the stored input.
+func (rw *GenericFunction[T, U, E]) jobResultJSON(r *Runtime, jobid string) (outputz []byte, errjsonz []byte, syserr error) {
+	jid, err := decodeJobID(jobid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if jid.Handler != encodeHandlerName(rw.options) {
+		return nil, nil, ErrJobHandlerMismatch
+	}
+	if err := r.enforceAuth(rw.options); err != nil {
+		return nil, nil, err
+	}
+
+	c := r.server.jobStrategy()
+	if c == nil {
+		return nil, nil, ErrJobNotFound
+	}
+	ji, injson, err := c.Input(r.Context(), jobid)
+	if err != nil {
+		return nil, nil, err
+	}
+	input, err := rw.tpool.New(func(a any) error {
+		return json.Unmarshal(injson, a)
+	})
+	if err != nil {
+		return nil, nil, ErrJobInputDecodeFailed.With(err)
+	}
+	if err := r.enforceACL(rw.options, input); err != nil {
+		return nil, nil, err
+	}
+	if ji.Meta.Status != statusDone && ji.Meta.Status != statusError {
+		return nil, nil, NewJobPendingError(jobid, "job not finished yet")
+	}
+
+	output, err := rw.JobResult(r, jobid)
+	var pending *JobPendingError
+	if errors.As(err, &pending) {
+		return nil, nil, pending
+	}
+	return marshalOutputSet[U, E](output, err)
+}
+
 func (rw *GenericFunction[T, U, E]) JobResult(r *Runtime, jobid string) (output U, err error) {
 	var zeroU U
 	var syserr error
@@ -461,8 +538,12 @@ func (rw *GenericFunction[T, U, E]) JobResult(r *Runtime, jobid string) (output
 		return zeroU, ErrJobHandlerMismatch
 	}
 
+	c := r.server.jobStrategy()
+	if c == nil {
+		return zeroU, ErrJobNotFound
+	}
 	unmarshalfn := unmarshalfnMake[U, E](r, rw.options, rw.upool, rw.epool, jid.Handler)
-	output, err, syserr = unmarshalfn(r.server.callSQLStrategy.Result(r.Context(), jobid, rw.options.Job.CacheExpire != 0))
+	output, err, syserr = unmarshalfn(c.Result(r.Context(), jobid, rw.options.Job.CacheExpire != 0))
 
 	if syserr == nil {
 		return output, err
@@ -474,6 +555,14 @@ func (rw *GenericFunction[T, U, E]) JobResult(r *Runtime, jobid string) (output
 	return zeroU, syserr
 }
 
+// jobStrategy is the store of job inputs and results, nil when no job backend is configured.
+func (s *Server) jobStrategy() callStrategy {
+	if s.callSQLStrategy == nil {
+		return nil
+	}
+	return s.callSQLStrategy
+}
+
 type callStrategy interface {
 	Init(ctx context.Context, allow_migrate bool) error
 
@@ -504,6 +593,9 @@ type callStrategy interface {
 	// Find completed job. (non-blocking)
 	Result(ctx context.Context, key string, volatile bool) (meta JobInfo, outjson []byte, errjson []byte, err error)
 
+	// Find a job and its input, queued or completed. (non-blocking)
+	Input(ctx context.Context, key string) (meta JobInfo, injson []byte, err error)
+
 	// List jobs
 	List(ctx context.Context, statuses []int, offset, limit int) ([]JobInfo, error)
 
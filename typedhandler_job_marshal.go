@@ -113,6 +113,10 @@ func hasSelfDiscovery(t reflect.Type) bool {
 		// 3. 構造体の場合は全フィールドをスキャン
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
+			// 非公開フィールドは marshal されないのでスキップ
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
 			// フィールドの型を再帰的にチェック
 			if hasSelfDiscovery(field.Type) {
 				return true
@@ -32,6 +32,10 @@ var sqlStatusCodeStrings = []string{
 
 type functionInvoker = func(r *Runtime, handler, version string, injson []byte, direct bool, infunc func(input any) error) (key string, outjson []byte, err []byte, syserr error)
 
+type functionCaller = func(r *Runtime, injson []byte, infunc func(input any) error) (outjson []byte, err []byte, syserr error)
+
+type functionJobResult = func(r *Runtime, jobid string) (outjson []byte, err []byte, syserr error)
+
 type callSQLStrategy struct {
 	name     string
 	db       *sql.DB
@@ -596,6 +600,34 @@ func (c *callSQLStrategy) Result(
 	return ji, out, errb, nil
 }
 
+func (c *callSQLStrategy) Input(
+	ctx context.Context,
+	key string,
+) (JobInfo, []byte, error) {
+
+	// results are written on completion, a queued or leased job only exists in executions.
+	query := `
+	SELECT key, handler, version, status, input
+	FROM %s
+	WHERE key = ?
+	`
+	var ji JobInfo
+	var input []byte
+	for _, table := range []string{"executions_results", "executions"} {
+		err := c.db.QueryRowContext(ctx, fmt.Sprintf(query, table), key).Scan(
+			&ji.JobID,
+			&ji.Handler,
+			&ji.Meta.Version,
+			&ji.Meta.Status,
+			&input,
+		)
+		if err == nil {
+			return ji, input, nil
+		}
+	}
+	return ji, nil, ErrJobNotFound
+}
+
 func (c *callSQLStrategy) Wait(
 	ctx context.Context,
 	key string,
@@ -94,7 +94,12 @@ func getIdempotentKey(input any) string {
 	return ""
 }
 
+// encodeHandlerName is the job and cache identity of a function, "name@v2" for APIVersion "v2",
+// so the versions of one Name keep their own jobs.
 func encodeHandlerName(opt *Option) string {
+	if opt.APIVersion != "" {
+		return opt.Name + "@" + strings.ReplaceAll(strings.Trim(opt.APIVersion, "/"), jobIDSep, "_")
+	}
 	return opt.Name //+ "@" + handlerVersion(opt)
 }
 
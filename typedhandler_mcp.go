@@ -1,18 +1,23 @@
 package allino
 
 import (
+	"bufio"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mime"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"github.com/goccy/go-yaml"
@@ -33,6 +38,27 @@ type MCPConfig struct {
 	ResourceDirs   []string `json:"resourceDirs"`
 	ResourceScheme string   `json:"resourceScheme"`
 	ResourceHost   string   `json:"resourceHost"`
+
+	// authentication
+	RequireAuth          bool     `json:"requireAuth"`          // answer 401 without a valid access token
+	Resource             string   `json:"resource"`             // canonical resource URI, defaults to the request base URL + endpoint
+	AuthorizationServers []string `json:"authorizationServers"` // defaults to the request base URL
+	Scopes               []string `json:"scopes"`
+	FilesACLResource     string   `json:"filesACLResource"` // Casbin resource of promptDirs and resourceDirs files, {name} is the file name. Public when empty
+	FilesACLAction       string   `json:"filesACLAction"`
+
+	// transport
+	Stateless      bool          `json:"stateless"`      // do not issue Mcp-Session-Id
+	SessionTimeout time.Duration `json:"sessionTimeout"` // idle sessions are closed, defaults to 1h
+
+	// notifications
+	NotifyChannel string        `json:"notifyChannel"` // Redis pub/sub channel shared by the nodes, defaults to allino:mcp:notify
+	WatchInterval time.Duration `json:"watchInterval"` // polling of resourceDirs and promptDirs, defaults to 2s, negative disables
+
+	PageSize int `json:"pageSize"` // items per page of the list methods, defaults to 100
+
+	// remote servers, see MCPServer
+	Servers map[string]MCPServerConfig `json:"servers"`
 }
 
 type mcpLocalResource struct {
@@ -60,7 +86,8 @@ var MCPExtension = NewExtension[MCPConfig, any](
 	&ExtOption{
 		OnInit: func(s *Server, virtual *Runtime) error {
 			config := mcpConfig()
-			if len(config.PromptDirs) == 0 && len(config.ResourceDirs) == 0 {
+			gateway := registerMCPGateways(s)
+			if !gateway && len(config.PromptDirs) == 0 && len(config.ResourceDirs) == 0 {
 				return nil
 			}
 			registerMCPHandlers(s)
@@ -73,6 +100,10 @@ var MCPExtension = NewExtension[MCPConfig, any](
 			registerMCPHandlers(s)
 			return nil
 		},
+		OnShutdown: func(s *Server, virtual *Runtime) error {
+			closeMCPGateways()
+			return nil
+		},
 	},
 )
 
@@ -85,13 +116,17 @@ func registerMCPHandlers(s *Server) {
 		return handleMCPRequest(s, c)
 	})
 	s.HandleFiber(http.MethodGet, endpoint, func(c *fiber.Ctx) error {
-		return c.JSON(map[string]any{
-			"name":      s.Config.AppName,
-			"endpoint":  endpoint,
-			"protocol":  "2024-11-05",
-			"transport": "streamable-http",
-		})
+		return handleMCPGet(s, c)
+	})
+	s.HandleFiber(http.MethodDelete, endpoint, func(c *fiber.Ctx) error {
+		return handleMCPDelete(s, c)
 	})
+	s.HandleFiber(http.MethodGet, mcpProtectedResourceEndpoint(), func(c *fiber.Ctx) error {
+		return handleMCPProtectedResource(s, c)
+	})
+	reapMCPSessions(s)
+	subscribeMCPNotifications(s)
+	watchMCPDirs(s)
 }
 
 func mcpConfig() *MCPConfig {
@@ -159,61 +194,197 @@ type mcpRPCError struct {
 }
 
 func handleMCPRequest(s *Server, c *fiber.Ctx) error {
+	r := NewRuntime(s, c)
+	r.cache.req_type = REQUEST_HTTP
+	if ok, err := mcpAuthenticate(r, c); !ok {
+		return err
+	}
+
+	session, status, msg := mcpRequestSession(s, r, c)
+	if status != 0 {
+		return c.Status(status).JSON(mcpError(nil, -32000, msg))
+	}
+	r.memo.mcpSession = session
+
+	body := c.Body()
+	if isMCPBatch(body) {
+		return handleMCPBatch(s, r, c, body)
+	}
+
 	var req mcpJSONRPCRequest
-	if err := json.Unmarshal(c.Body(), &req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32700, err.Error()))
 	}
-	hasID := len(req.ID) > 0
-	if !hasID {
-		req.ID = []byte("null")
+	if mcpSessionRequired(r, &req) {
+		return c.Status(http.StatusBadRequest).JSON(mcpError(req.ID, -32000, mcpSessionHeader+" is required"))
 	}
 
-	r := NewRuntime(s, c)
-	r.cache.req_type = REQUEST_HTTP
-	result, err := dispatchMCPRequest(s, r, &req)
-	if err != nil {
-		mcpLogError(r, "request", req.Method, "dispatch", err)
-		return c.JSON(mcpError(req.ID, -32603, err.Error()))
+	if len(req.ID) > 0 && acceptsEventStream(c.Get("Accept")) && mcpIsToolCall(s, &req) {
+		return handleMCPEventStream(s, r, &req)
 	}
 
-	if !hasID {
+	resp := handleMCPMessage(s, r, c, &req)
+	if resp == nil {
 		return c.SendStatus(http.StatusAccepted)
 	}
+	return c.JSON(resp)
+}
 
-	return c.JSON(mcpJSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result:  result,
+type mcpJSONRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// mcpIsToolCall reports whether req calls a known tool, which is answered with SSE when the
+// client accepts it so that long-running calls can send notifications before the result.
+func mcpIsToolCall(s *Server, req *mcpJSONRPCRequest) bool {
+	if req.Method != "tools/call" {
+		return false
+	}
+	var p struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(req.Params, &p); err != nil {
+		return false
+	}
+	return findMCPOption(s, "tool", p.Name) != nil
+}
+
+func mcpProgressToken(params json.RawMessage, id json.RawMessage) any {
+	var p struct {
+		Meta struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &p); err == nil && p.Meta.ProgressToken != nil {
+		return p.Meta.ProgressToken
+	}
+	var fallback any
+	_ = json.Unmarshal(id, &fallback)
+	return fallback
+}
+
+// mcpProgressSink turns every streamed item into a notifications/progress message.
+func mcpProgressSink(token any, send func(buf []byte) error) func(v any) error {
+	progress := 0
+	return func(v any) error {
+		text, err := marshalMCPText(v)
+		if err != nil {
+			return err
+		}
+		progress++
+		buf, err := json.Marshal(mcpJSONRPCNotification{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params: map[string]any{
+				"progressToken": token,
+				"progress":      progress,
+				"message":       text,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return send(buf)
+	}
+}
+
+// handleMCPEventStream answers a tools/call with SSE:
+// every streamed item becomes notifications/progress, followed by the JSON-RPC response.
+func handleMCPEventStream(s *Server, r *Runtime, req *mcpJSONRPCRequest) error {
+	c := r.fiber
+	c.Set("Content-Type", EventStream)
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	token := mcpProgressToken(req.Params, req.ID)
+	// the fiber context is released before the body is written
+	r = r.detached()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		r.memo.mcpSend = func(buf []byte) error {
+			return writeSSE(w, "message", buf)
+		}
+		r.memo.streamSink = mcpProgressSink(token, r.memo.mcpSend)
+
+		var resp mcpJSONRPCResponse
+		result, err := dispatchMCPRequest(s, r, req)
+		if err != nil {
+			mcpLogError(r, "request", req.Method, "dispatch", err)
+			resp = mcpError(req.ID, -32603, err.Error())
+		} else {
+			resp = mcpJSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Result:  result,
+			}
+		}
+		buf, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		_ = writeSSE(w, "message", buf)
 	})
+	return nil
 }
 
 func dispatchMCPRequest(s *Server, r *Runtime, req *mcpJSONRPCRequest) (any, error) {
+	ctx, end := beginMCPRequest(r, req)
+	if end != nil {
+		defer end()
+	}
+	result, err := dispatchMCPMethod(s, r, req)
+	if ctx != nil && errors.Is(context.Cause(ctx), errMCPRequestCancelled) {
+		return nil, errMCPRequestCancelled
+	}
+	return result, err
+}
+
+func dispatchMCPMethod(s *Server, r *Runtime, req *mcpJSONRPCRequest) (any, error) {
 	switch req.Method {
 	case "initialize":
 		return map[string]any{
-			"protocolVersion": "2024-11-05",
+			"protocolVersion": negotiateMCPProtocol(req.Params),
 			"capabilities": map[string]any{
-				"tools":     map[string]any{},
-				"resources": map[string]any{},
-				"prompts":   map[string]any{},
+				"tools":       map[string]any{},
+				"resources":   map[string]any{"subscribe": true, "listChanged": true},
+				"prompts":     map[string]any{"listChanged": true},
+				"logging":     map[string]any{},
+				"completions": map[string]any{},
 			},
 			"serverInfo": map[string]any{
 				"name":    s.Config.AppName,
 				"version": s.Config.Version,
 			},
 		}, nil
-	case "notifications/initialized":
+	case "notifications/initialized", "ping":
 		return map[string]any{}, nil
+	case "notifications/cancelled":
+		return mcpCancelRequest(r, req.Params)
+	case "logging/setLevel":
+		return mcpSetLogLevel(r, req.Params)
+	case "completion/complete":
+		return mcpComplete(s, r, req.Params)
 	case "tools/list":
-		return mcpToolsList(s)
+		result, err := mcpToolsList(s, r)
+		return mcpPaginate(req.Params, "tools", result, err)
 	case "tools/call":
 		return mcpToolCall(s, r, req.Params)
 	case "resources/list":
-		return mcpResourcesList(s)
+		result, err := mcpResourcesList(s, r)
+		return mcpPaginate(req.Params, "resources", result, err)
+	case "resources/templates/list":
+		result, err := mcpResourceTemplatesList(s, r)
+		return mcpPaginate(req.Params, "resourceTemplates", result, err)
 	case "resources/read":
 		return mcpResourceRead(s, r, req.Params)
+	case "resources/subscribe":
+		return mcpResourceSubscribe(s, r, req.Params, true)
+	case "resources/unsubscribe":
+		return mcpResourceSubscribe(s, r, req.Params, false)
 	case "prompts/list":
-		return mcpPromptsList(s)
+		result, err := mcpPromptsList(s, r)
+		return mcpPaginate(req.Params, "prompts", result, err)
 	case "prompts/get":
 		return mcpPromptGet(s, r, req.Params)
 	default:
@@ -239,13 +410,13 @@ func mcpOptions(s *Server, kind string) []*Option {
 	var out []*Option
 	for _, h := range s.FunctionCache {
 		opt := h.Options()
-		if opt.invoker != nil && strings.EqualFold(opt.MCP, kind) && mcpFunctionName(opt) != "" {
+		if opt.caller != nil && strings.EqualFold(opt.MCP, kind) && mcpFunctionName(opt) != "" {
 			out = append(out, opt)
 		}
 	}
 	for _, h := range s.internalHandlerCache {
 		opt := h.Options()
-		if opt.invoker != nil && strings.EqualFold(opt.MCP, kind) && mcpFunctionName(opt) != "" {
+		if opt.caller != nil && strings.EqualFold(opt.MCP, kind) && mcpFunctionName(opt) != "" {
 			out = append(out, opt)
 		}
 	}
@@ -274,6 +445,9 @@ func mcpFunctionName(opt *Option) string {
 	if name == "" {
 		return ""
 	}
+	if opt.APIVersion != "" {
+		name += "_" + opt.APIVersion
+	}
 	name = mcpNameRe.ReplaceAllString(name, "_")
 	name = strings.Trim(name, "_")
 	return name
@@ -287,7 +461,7 @@ func mcpInputSchemaMap(opt *Option) (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
-	return mcpSchemaToMap(schema)
+	return mcpAnnotatedSchemaMap(schema, opt.InputType(), mcpSchemaExamples(opt, false))
 }
 
 func mcpOutputSchemaMap(opt *Option) (map[string]any, error) {
@@ -298,7 +472,37 @@ func mcpOutputSchemaMap(opt *Option) (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
-	return mcpSchemaToMap(schema)
+	out, err := mcpAnnotatedSchemaMap(schema, opt.OutputType(), mcpSchemaExamples(opt, true))
+	if err != nil || !opt.streaming {
+		return out, err
+	}
+	// structuredContent must be an object, streamed items are returned as {"items": [...]}.
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"items": out},
+		"required":   []string{"items"},
+	}, nil
+}
+
+// mcpStreamResult wraps the items of a streaming function like its outputSchema.
+func mcpStreamResult(output any) any {
+	if isReallyNil(output) {
+		output = []any{}
+	}
+	return map[string]any{"items": output}
+}
+
+// mcpAnnotatedSchemaMap adds the field tags of t and the function examples to the schema.
+func mcpAnnotatedSchemaMap(schema *jsonino.Schema, t reflect.Type, examples []any) (map[string]any, error) {
+	out, err := mcpSchemaToMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	annotateSchemaMap(out, t)
+	if len(examples) > 0 {
+		out["examples"] = examples
+	}
+	return out, nil
 }
 
 func mcpSchemaToMap(schema *jsonino.Schema) (map[string]any, error) {
@@ -316,23 +520,33 @@ func mcpSchemaToMap(schema *jsonino.Schema) (map[string]any, error) {
 	return out, nil
 }
 
-func mcpToolsList(s *Server) (any, error) {
+func mcpToolsList(s *Server, r *Runtime) (any, error) {
 	tools := []map[string]any{}
-	for _, opt := range mcpOptions(s, "tool") {
+	for _, opt := range mcpListOptions(s, r, "tool") {
+		if opt.mcpTool != nil {
+			tools = append(tools, mcpGatewayToolEntry(opt))
+			continue
+		}
 		inputSchema, err := mcpInputSchemaMap(opt)
 		if err != nil {
 			return nil, err
 		}
-		outputSchema, err := mcpOutputSchemaMap(opt)
-		if err != nil {
-			return nil, err
+		tool := map[string]any{
+			"name":        mcpFunctionName(opt),
+			"description": opt.Description,
+			"inputSchema": inputSchema,
 		}
-		tools = append(tools, map[string]any{
-			"name":         mcpFunctionName(opt),
-			"description":  opt.Description,
-			"inputSchema":  inputSchema,
-			"outputSchema": outputSchema,
-		})
+		if !mcpRichOutput(opt) {
+			outputSchema, err := mcpOutputSchemaMap(opt)
+			if err != nil {
+				return nil, err
+			}
+			tool["outputSchema"] = outputSchema
+		}
+		if annotations := mcpToolAnnotations(opt); annotations != nil {
+			tool["annotations"] = annotations
+		}
+		tools = append(tools, tool)
 	}
 	return map[string]any{"tools": tools}, nil
 }
@@ -341,6 +555,9 @@ func mcpToolCall(s *Server, r *Runtime, params json.RawMessage) (any, error) {
 	var p struct {
 		Name      string          `json:"name"`
 		Arguments json.RawMessage `json:"arguments"`
+		Meta      struct {
+			JobID string `json:"allino/jobid"`
+		} `json:"_meta"`
 	}
 	if err := json.Unmarshal(params, &p); err != nil {
 		mcpLogError(r, "tool", "", "params", err)
@@ -353,7 +570,59 @@ func mcpToolCall(s *Server, r *Runtime, params json.RawMessage) (any, error) {
 		return nil, err
 	}
 	mcpLogInfo(r, "mcp tool started", "tool", p.Name)
-	output, err := callMCPFunction(s, r, opt, p.Arguments)
+	if opt.mcpTool != nil {
+		result, err := mcpGatewayToolCall(r, opt, p.Arguments)
+		if err != nil {
+			mcpLogError(r, "tool", p.Name, "gateway", err)
+			return nil, err
+		}
+		mcpLogInfo(r, "mcp tool completed", "tool", p.Name)
+		return result, nil
+	}
+	var streamed []any
+	if opt.streaming && r.memo.streamSink != nil {
+		sink := r.memo.streamSink
+		streamed = []any{}
+		r.memo.streamSink = func(v any) error {
+			streamed = append(streamed, v)
+			return sink(v)
+		}
+	}
+	var output any
+	var err error
+	if p.Meta.JobID != "" {
+		output, err = pollMCPJob(r, opt, p.Meta.JobID)
+	} else {
+		output, err = callMCPFunction(s, r, opt, p.Arguments)
+	}
+	if err == nil && isReallyNil(output) && streamed != nil {
+		output = streamed
+	}
+	var pending *JobPendingError
+	if errors.As(err, &pending) && r.memo.mcpSend != nil {
+		// the client asked for progress, keep the call open until the job is done.
+		if token, ok := mcpExplicitProgressToken(params); ok {
+			output, err = waitMCPJob(r, opt, pending.JobID, token)
+		}
+	}
+	if errors.As(err, &pending) {
+		meta := map[string]any{
+			"allino/jobid":  pending.JobID,
+			"allino/status": "pending",
+		}
+		if progress, total := mcpJobProgress(r, pending.JobID); total > 0 {
+			meta["allino/progress"] = progress
+			meta["allino/total"] = total
+		}
+		// calling the tool again with _meta."allino/jobid" polls the job.
+		return map[string]any{
+			"content": []map[string]any{{
+				"type": "text",
+				"text": fmt.Sprintf("job %s is pending: %s. Call %s with _meta {\"allino/jobid\": %q} to get the result.", pending.JobID, pending.Msg, p.Name, pending.JobID),
+			}},
+			"_meta": meta,
+		}, nil
+	}
 	if err != nil {
 		mcpLogError(r, "tool", p.Name, "call", err)
 		return map[string]any{
@@ -364,6 +633,21 @@ func mcpToolCall(s *Server, r *Runtime, params json.RawMessage) (any, error) {
 			}},
 		}, nil
 	}
+	if streamed == nil && mcpRichOutput(opt) {
+		content, err := mcpToolContent(opt, output)
+		if err != nil {
+			mcpLogError(r, "tool", p.Name, "content", err)
+			return nil, err
+		}
+		mcpLogInfo(r, "mcp tool completed", "tool", p.Name)
+		if content == nil {
+			content = []MCPContent{}
+		}
+		return map[string]any{"content": content}, nil
+	}
+	if opt.streaming {
+		output = mcpStreamResult(output)
+	}
 	text, err := marshalMCPText(output)
 	if err != nil {
 		mcpLogError(r, "tool", p.Name, "marshal", err)
@@ -379,12 +663,16 @@ func mcpToolCall(s *Server, r *Runtime, params json.RawMessage) (any, error) {
 	}, nil
 }
 
-func mcpResourcesList(s *Server) (any, error) {
+func mcpResourcesList(s *Server, r *Runtime) (any, error) {
 	resources := []map[string]any{}
 	seen := map[string]bool{}
-	for _, opt := range mcpOptions(s, "resource") {
+	for _, opt := range mcpListOptions(s, r, "resource") {
+		// functions with path variables are listed by resources/templates/list
+		if t := newMCPURITemplate(opt); len(t.path) > 0 {
+			continue
+		}
 		name := mcpFunctionName(opt)
-		uri := "allino://" + name
+		uri := mcpFunctionResourcePrefix + name
 		seen[uri] = true
 		resources = append(resources, map[string]any{
 			"uri":         uri,
@@ -398,7 +686,7 @@ func mcpResourcesList(s *Server) (any, error) {
 		return nil, err
 	}
 	for _, resource := range localResources {
-		if seen[resource.URI] {
+		if seen[resource.URI] || !mcpFileAllowed(r, resource.Name) {
 			continue
 		}
 		seen[resource.URI] = true
@@ -424,15 +712,35 @@ func mcpResourceRead(s *Server, r *Runtime, params json.RawMessage) (any, error)
 		mcpLogError(r, "resource", "", "params", err)
 		return nil, err
 	}
-	name := strings.TrimPrefix(p.URI, "allino://")
-	opt := findMCPOption(s, "resource", name)
+	name, opt, template, segments, query := mcpFindResourceFunction(s, p.URI)
 	if opt != nil {
 		mcpLogInfo(r, "mcp resource started", "resource", name)
-		output, err := callMCPFunction(s, r, opt, p.Arguments)
+		uriArgs, err := template.arguments(segments, query)
+		if err != nil {
+			mcpLogError(r, "resource", name, "uri", err)
+			return nil, err
+		}
+		args, err := mcpResourceArguments(p.Arguments, uriArgs)
+		if err != nil {
+			mcpLogError(r, "resource", name, "params", err)
+			return nil, err
+		}
+		output, err := callMCPFunction(s, r, opt, args)
 		if err != nil {
 			mcpLogError(r, "resource", name, "call", err)
 			return nil, err
 		}
+		if mcpBinaryOutput(opt) {
+			data, err := mcpBinaryContent(output)
+			if err != nil {
+				mcpLogError(r, "resource", name, "content", err)
+				return nil, err
+			}
+			mcpLogInfo(r, "mcp resource completed", "resource", name)
+			return map[string]any{
+				"contents": []*MCPEmbeddedResource{MCPResource(p.URI, opt.ContentType, data).Resource},
+			}, nil
+		}
 		text, err := marshalMCPText(output)
 		if err != nil {
 			mcpLogError(r, "resource", name, "marshal", err)
@@ -453,7 +761,7 @@ func mcpResourceRead(s *Server, r *Runtime, params json.RawMessage) (any, error)
 		mcpLogError(r, "resource", p.URI, "local_lookup", err)
 		return nil, err
 	}
-	if resource == nil {
+	if resource == nil || !mcpFileAllowed(r, resource.Name) {
 		err := fmt.Errorf("MCP resource not found: %s", p.URI)
 		mcpLogError(r, "resource", name, "lookup", err)
 		return nil, err
@@ -470,10 +778,43 @@ func mcpResourceRead(s *Server, r *Runtime, params json.RawMessage) (any, error)
 	}, nil
 }
 
-func mcpPromptsList(s *Server) (any, error) {
+// mcpFindResourceFunction returns the resource function of uri, or nil for a local resource.
+func mcpFindResourceFunction(s *Server, uri string) (name string, opt *Option, template *mcpURITemplate, segments []string, query url.Values) {
+	name, segments, query, isFunction := mcpSplitFunctionURI(uri)
+	if !isFunction {
+		return name, nil, nil, segments, query
+	}
+	// local resources share the scheme, a function matches with its number of path variables
+	if opt = findMCPOption(s, "resource", name); opt != nil {
+		if template = newMCPURITemplate(opt); len(template.path) != len(segments) {
+			opt = nil
+		}
+	}
+	return name, opt, template, segments, query
+}
+
+// mcpResourceAllowed resolves uri like resources/read and checks that r may read it.
+func mcpResourceAllowed(s *Server, r *Runtime, uri string) error {
+	if _, opt, _, _, _ := mcpFindResourceFunction(s, uri); opt != nil {
+		if !mcpAllowed(r, opt) {
+			return fmt.Errorf("MCP resource not found: %s", uri)
+		}
+		return nil
+	}
+	resource, err := mcpFindLocalResource(s, uri)
+	if err != nil {
+		return err
+	}
+	if resource == nil || !mcpFileAllowed(r, resource.Name) {
+		return fmt.Errorf("MCP resource not found: %s", uri)
+	}
+	return nil
+}
+
+func mcpPromptsList(s *Server, r *Runtime) (any, error) {
 	prompts := []map[string]any{}
 	seen := map[string]bool{}
-	for _, opt := range mcpOptions(s, "prompt") {
+	for _, opt := range mcpListOptions(s, r, "prompt") {
 		schema, err := mcpInputSchemaMap(opt)
 		if err != nil {
 			return nil, err
@@ -491,7 +832,7 @@ func mcpPromptsList(s *Server) (any, error) {
 		return nil, err
 	}
 	for _, prompt := range markdownPrompts {
-		if seen[prompt.Name] {
+		if seen[prompt.Name] || !mcpFileAllowed(r, prompt.Name) {
 			continue
 		}
 		seen[prompt.Name] = true
@@ -572,7 +913,7 @@ func mcpPromptGet(s *Server, r *Runtime, params json.RawMessage) (any, error) {
 		mcpLogError(r, "prompt", p.Name, "markdown_lookup", err)
 		return nil, err
 	}
-	if prompt == nil {
+	if prompt == nil || !mcpFileAllowed(r, prompt.Name) {
 		err := fmt.Errorf("MCP prompt not found: %s", p.Name)
 		mcpLogError(r, "prompt", p.Name, "lookup", err)
 		return nil, err
@@ -599,12 +940,29 @@ func callMCPFunction(s *Server, r *Runtime, opt *Option, args json.RawMessage) (
 		zap.String("mcp", opt.MCP),
 		zap.String("tool", mcpFunctionName(opt)),
 	)
-	_, outputJSON, errJSON, syserr := opt.invoker(r, encodeHandlerName(opt), handlerVersion(opt), args, false, func(input any) error {
+	outputJSON, errJSON, syserr := opt.caller(r, args, func(input any) error {
 		if s.Config.System.DisableValidator {
 			return nil
 		}
 		return s.Validator.Struct(input)
 	})
+	return decodeMCPOutput(r, opt, outputJSON, errJSON, syserr)
+}
+
+func pollMCPJob(r *Runtime, opt *Option, jobid string) (any, error) {
+	if opt.jobResult == nil {
+		return nil, ErrJobNotFound
+	}
+	outputJSON, errJSON, syserr := opt.jobResult(r, jobid)
+	return decodeMCPOutput(r, opt, outputJSON, errJSON, syserr)
+}
+
+func decodeMCPOutput(r *Runtime, opt *Option, outputJSON, errJSON []byte, syserr error) (any, error) {
+	var pending *JobPendingError
+	if errors.As(syserr, &pending) {
+		mcpLogInfo(r, "mcp job pending", opt.MCP, mcpFunctionName(opt))
+		return nil, pending
+	}
 	if syserr != nil {
 		mcpLogError(r, opt.MCP, mcpFunctionName(opt), "system", syserr)
 		return nil, syserr
@@ -655,8 +1013,12 @@ func marshalMCPText(v any) (string, error) {
 }
 
 func mcpLoadLocalResources(s *Server) ([]mcpLocalResource, error) {
+	return mcpLoadResourceDirs(s, mcpConfig().ResourceDirs)
+}
+
+func mcpLoadResourceDirs(s *Server, dirs []string) ([]mcpLocalResource, error) {
 	resources := []mcpLocalResource{}
-	for _, dir := range mcpConfig().ResourceDirs {
+	for _, dir := range dirs {
 		root := mcpResolvePath(s, dir)
 		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 			if err != nil {
@@ -0,0 +1,113 @@
+package allino
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wh-kuromai/cryptino"
+)
+
+const mcpProtectedResourcePath = "/.well-known/oauth-protected-resource"
+
+// mcpProtectedResourceEndpoint is the RFC 9728 metadata path of the MCP endpoint.
+func mcpProtectedResourceEndpoint() string {
+	return mcpProtectedResourcePath + mcpEndpoint()
+}
+
+func mcpResourceURL(c *fiber.Ctx) string {
+	if resource := strings.TrimSpace(mcpConfig().Resource); resource != "" {
+		return resource
+	}
+	return c.BaseURL() + strings.TrimPrefix(c.Path(), mcpProtectedResourcePath)
+}
+
+func handleMCPProtectedResource(s *Server, c *fiber.Ctx) error {
+	config := mcpConfig()
+	servers := config.AuthorizationServers
+	if len(servers) == 0 {
+		servers = []string{c.BaseURL()}
+	}
+	metadata := map[string]any{
+		"resource":                 mcpResourceURL(c),
+		"authorization_servers":    servers,
+		"bearer_methods_supported": []string{"header"},
+		"resource_name":            s.Config.AppName,
+	}
+	if len(config.Scopes) > 0 {
+		metadata["scopes_supported"] = config.Scopes
+	}
+	return c.JSON(metadata)
+}
+
+// mcpAuthenticate answers 401 with a WWW-Authenticate challenge when MCPConfig.RequireAuth
+// is set and the request carries no valid access token. Login cookies and the debug .user
+// query are not accepted, only the bearer token of the Authorization header.
+func mcpAuthenticate(r *Runtime, c *fiber.Ctx) (ok bool, err error) {
+	if !mcpConfig().RequireAuth {
+		return true, nil
+	}
+	if mcpBearerUser(r, c) {
+		return true, nil
+	}
+	mcpLogInfo(r, "mcp unauthorized", "request", c.Path())
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer resource_metadata="`+c.BaseURL()+mcpProtectedResourcePath+c.Path()+`"`)
+	return false, c.Status(http.StatusUnauthorized).JSON(mcpError(nil, -32001, ErrLoginRequired.Error()))
+}
+
+// mcpBearerUser reports whether the Authorization header has an access token of the user of r.
+func mcpBearerUser(r *Runtime, c *fiber.Ctx) bool {
+	header := c.Get(fiber.HeaderAuthorization)
+	pub := r.config.Login.PublicKey
+	if !strings.HasPrefix(header, "Bearer ") || pub == nil {
+		return false
+	}
+	token, err := cryptino.VerifyJWT(cryptino.ES256(), []byte(strings.TrimSpace(header[len("Bearer "):])), pub)
+	if err != nil || token.Body.Audience != r.config.Login.OAuth.JWTAudience {
+		return false
+	}
+	uid, _, _, err := r.User()
+	return err == nil && uid == token.Body.Subject
+}
+
+// mcpListOptions returns the functions of kind the caller may use. r is nil from the CLI,
+// which lists everything.
+func mcpListOptions(s *Server, r *Runtime, kind string) []*Option {
+	opts := mcpOptions(s, kind)
+	if r == nil {
+		return opts
+	}
+	out := opts[:0]
+	for _, opt := range opts {
+		if mcpAllowed(r, opt) {
+			out = append(out, opt)
+		}
+	}
+	return out
+}
+
+// mcpAllowed checks Option.Auth and the ACL of opt. ACL templates that need input
+// variables are checked again on the call.
+func mcpAllowed(r *Runtime, opt *Option) bool {
+	if r.enforceAuth(opt) != nil {
+		return false
+	}
+	err := r.enforceACL(opt, nil)
+	return err == nil || errors.Is(err, ErrACLVariableMissing)
+}
+
+type mcpFileACLInput struct {
+	Name string `acl:"name"`
+}
+
+// mcpFileAllowed checks mcp.filesACLResource for a file of promptDirs or resourceDirs.
+// r is nil from the CLI, which lists everything.
+func mcpFileAllowed(r *Runtime, name string) bool {
+	config := mcpConfig()
+	if r == nil || config.FilesACLResource == "" {
+		return true
+	}
+	opt := &Option{ACLResource: config.FilesACLResource, ACLAction: config.FilesACLAction}
+	return r.enforceACL(opt, &mcpFileACLInput{Name: name}) == nil
+}
@@ -0,0 +1,595 @@
+package allino
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultMCPClientTimeout = 30 * time.Second
+
+// MCPServerConfig is a remote MCP server, reached over streamable HTTP (URL) or stdio (Command).
+type MCPServerConfig struct {
+	URL     string            `json:"url"`     // streamable HTTP endpoint
+	Command string            `json:"command"` // stdio: the server process, used when URL is empty
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"` // added to the environment of the process
+
+	// authentication
+	Token  string            `json:"token"`  // optional: sent as "Authorization: Bearer"
+	Header map[string]string `json:"header"` // optional: added to every HTTP request
+
+	Prefix  string        `json:"prefix"`  // prepended to the tool names, e.g. "github_"
+	Tools   []string      `json:"tools"`   // optional: only these remote tools, defaults to all
+	Gateway bool          `json:"gateway"` // re-export the tools on this server's MCP endpoint
+	Timeout time.Duration `json:"timeout"` // per request, defaults to 30s
+
+	// access to the gateway tools, like Option.Auth, Option.ACLResource and Option.ACLAction
+	Auth        string `json:"auth"`
+	ACLResource string `json:"aclResource"`
+	ACLAction   string `json:"aclAction"`
+}
+
+// MCPClient calls the tools of a remote MCP server. It connects on first use.
+type MCPClient struct {
+	Name   string
+	Config MCPServerConfig
+	Client *http.Client // optional: HTTP transport, defaults to http.DefaultClient
+
+	configured bool     // Config is read from mcp.servers.<Name> on connect
+	tools      sync.Map // remote name -> *MCPTool
+
+	mu        sync.Mutex // serializes connect and close
+	connected bool
+	lastID    atomic.Int64
+
+	ioMu      sync.Mutex // guards the fields below and the writes to stdin
+	sessionID string
+	protocol  string
+
+	// stdio
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	pending map[string]chan *mcpClientMessage // request id -> response
+	exited  chan struct{}
+}
+
+// MCPToolResult is the result of tools/call on a remote server.
+type MCPToolResult struct {
+	Content           []MCPContent   `json:"content"`
+	StructuredContent any            `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
+	Meta              map[string]any `json:"_meta,omitempty"`
+}
+
+type mcpClientMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *mcpRPCError    `json:"error,omitempty"`
+}
+
+type mcpRemoteToolInfo struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+	Annotations  json.RawMessage `json:"annotations,omitempty"`
+}
+
+// mcpRemoteError is a JSON-RPC error answered by the remote server.
+type mcpRemoteError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *mcpRemoteError) Error() string {
+	return fmt.Sprintf("mcp %s: %s (%d)", e.Method, e.Message, e.Code)
+}
+
+var errMCPClientClosed = errors.New("mcp client closed")
+
+// NewMCPClient describes a remote MCP server, nothing is started until the first request.
+func NewMCPClient(name string, config MCPServerConfig) *MCPClient {
+	return &MCPClient{Name: name, Config: config}
+}
+
+var mcpClients sync.Map // server name -> *MCPClient
+
+// MCPServer returns the client of mcp.servers.<name> in the config, shared by the process.
+// The config is read when the client connects, so MCPServer can be used in var declarations:
+//
+//	var Assistant = allino.NewAI[In, Out](opt, model, prompt, allino.MCPServer("github").Tool("search_issues"))
+func MCPServer(name string) *MCPClient {
+	v, _ := mcpClients.LoadOrStore(name, &MCPClient{Name: name, configured: true})
+	return v.(*MCPClient)
+}
+
+// Connect sends initialize. Requests connect by themselves, Connect only reports errors early.
+func (c *MCPClient) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connectLocked(ctx)
+}
+
+func (c *MCPClient) connectLocked(ctx context.Context) error {
+	if c.connected {
+		return nil
+	}
+	if c.configured {
+		config, ok := mcpConfig().Servers[c.Name]
+		if !ok {
+			return fmt.Errorf("mcp server not configured: %s", c.Name)
+		}
+		c.Config = config
+	}
+	switch {
+	case c.Config.URL != "":
+	case c.Config.Command != "":
+		if err := c.startProcess(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("mcp server %s has neither url nor command", c.Name)
+	}
+
+	params := map[string]any{
+		"protocolVersion": mcpProtocolVersions[0],
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "allino", "version": mcpClientVersion()},
+	}
+	result, err := c.send(ctx, "initialize", params, true)
+	if err != nil {
+		c.closeLocked()
+		return err
+	}
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(result, &init); err != nil {
+		c.closeLocked()
+		return err
+	}
+	c.ioMu.Lock()
+	c.protocol = init.ProtocolVersion
+	c.ioMu.Unlock()
+	c.connected = true
+	if _, err := c.send(ctx, "notifications/initialized", nil, false); err != nil {
+		c.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// Close ends the session, or stops the process of a stdio server. The client reconnects on the next request.
+func (c *MCPClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeLocked()
+}
+
+func (c *MCPClient) closeLocked() error {
+	c.ioMu.Lock()
+	session := c.sessionID
+	c.ioMu.Unlock()
+	if c.Config.URL != "" && session != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout())
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.Config.URL, nil)
+		if err == nil {
+			c.setHeaders(req)
+			if resp, err := c.httpClient().Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+	c.connected = false
+	c.ioMu.Lock()
+	c.sessionID = ""
+	c.protocol = ""
+	c.ioMu.Unlock()
+
+	if c.cmd == nil {
+		return nil
+	}
+	c.stdin.Close()
+	select {
+	case <-c.exited:
+	case <-time.After(c.timeout()):
+		_ = c.cmd.Process.Kill()
+		<-c.exited
+	}
+	c.cmd = nil
+	return nil
+}
+
+// request sends method and returns its result. It reconnects once when the HTTP session
+// expired or the stdio process exited.
+func (c *MCPClient) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := c.Connect(ctx); err != nil {
+		return nil, err
+	}
+	result, err := c.send(ctx, method, params, true)
+	if errors.Is(err, errMCPSessionExpired) || errors.Is(err, errMCPClientClosed) {
+		c.mu.Lock()
+		c.closeLocked()
+		err = c.connectLocked(ctx)
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		result, err = c.send(ctx, method, params, true)
+	}
+	return result, err
+}
+
+func (c *MCPClient) send(ctx context.Context, method string, params any, hasID bool) (json.RawMessage, error) {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		msg["params"] = params
+	}
+	id := ""
+	if hasID {
+		id = strconv.FormatInt(c.lastID.Add(1), 10)
+		msg["id"] = json.RawMessage(id)
+	}
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout())
+		defer cancel()
+	}
+
+	var resp *mcpClientMessage
+	if c.Config.URL != "" {
+		resp, err = c.postHTTP(ctx, buf, id)
+	} else {
+		resp, err = c.writeStdio(ctx, buf, id)
+	}
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, &mcpRemoteError{Method: method, Code: resp.Error.Code, Message: resp.Error.Message}
+	}
+	return resp.Result, nil
+}
+
+func (c *MCPClient) timeout() time.Duration {
+	if c.Config.Timeout > 0 {
+		return c.Config.Timeout
+	}
+	return defaultMCPClientTimeout
+}
+
+// ListTools returns the remote tools, following nextCursor. Config.Tools limits the list.
+func (c *MCPClient) ListTools(ctx context.Context) ([]*MCPTool, error) {
+	infos, err := c.listTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tools := make([]*MCPTool, 0, len(infos))
+	for _, info := range infos {
+		tool := c.Tool(info.Name)
+		tool.setInfo(info)
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// Tools is ListTools as Functions, for NewAI or Option.Tools.
+func (c *MCPClient) Tools(ctx context.Context) ([]Function, error) {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Function, len(tools))
+	for i, tool := range tools {
+		out[i] = tool
+	}
+	return out, nil
+}
+
+func (c *MCPClient) listTools(ctx context.Context) ([]mcpRemoteToolInfo, error) {
+	var infos []mcpRemoteToolInfo
+	cursor := ""
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		result, err := c.request(ctx, "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tools      []mcpRemoteToolInfo `json:"tools"`
+			NextCursor string              `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return nil, err
+		}
+		for _, info := range page.Tools {
+			if len(c.Config.Tools) == 0 || containsString(c.Config.Tools, info.Name) {
+				infos = append(infos, info)
+			}
+		}
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return infos, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool calls a remote tool by its remote name. A tool error is a result with IsError.
+func (c *MCPClient) CallTool(ctx context.Context, name string, arguments any) (*MCPToolResult, error) {
+	result, err := c.callTool(ctx, name, arguments)
+	if err != nil {
+		return nil, err
+	}
+	var out MCPToolResult
+	if err := json.Unmarshal(result, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *MCPClient) callTool(ctx context.Context, name string, arguments any) (json.RawMessage, error) {
+	if arguments == nil {
+		arguments = map[string]any{}
+	}
+	return c.request(ctx, "tools/call", map[string]any{"name": name, "arguments": arguments})
+}
+
+func mcpClientVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/wh-kuromai/allino" {
+				return dep.Version
+			}
+		}
+	}
+	return "devel"
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// streamable HTTP
+
+var errMCPSessionExpired = errors.New("mcp session expired")
+
+func (c *MCPClient) httpClient() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+func (c *MCPClient) setHeaders(req *http.Request) {
+	for k, v := range c.Config.Header {
+		req.Header.Set(k, v)
+	}
+	if c.Config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Config.Token)
+	}
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	if c.sessionID != "" {
+		req.Header.Set(mcpSessionHeader, c.sessionID)
+	}
+	if c.protocol != "" {
+		req.Header.Set(mcpProtocolVersionHeader, c.protocol)
+	}
+}
+
+func (c *MCPClient) postHTTP(ctx context.Context, buf []byte, id string) (*mcpClientMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Config.URL, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", JSON)
+	req.Header.Set("Accept", JSON+", "+EventStream)
+	c.setHeaders(req)
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && req.Header.Get(mcpSessionHeader) != "" {
+		return nil, errMCPSessionExpired
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("mcp server %s: %s: %s", c.Name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if session := resp.Header.Get(mcpSessionHeader); session != "" {
+		c.ioMu.Lock()
+		c.sessionID = session
+		c.ioMu.Unlock()
+	}
+	if id == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), EventStream) {
+		return readMCPEventStream(resp.Body, id)
+	}
+	var msg mcpClientMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// readMCPEventStream returns the response to id, progress and other notifications are skipped.
+func readMCPEventStream(body io.Reader, id string) (*mcpClientMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), mcpStdioMaxMessage)
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+			continue
+		}
+		if line != "" || len(data) == 0 {
+			continue
+		}
+		var msg mcpClientMessage
+		if err := json.Unmarshal(data, &msg); err == nil && string(msg.ID) == id && msg.Method == "" {
+			return &msg, nil
+		}
+		data = data[:0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("mcp event stream ended without a response")
+}
+
+// stdio
+
+func (c *MCPClient) startProcess() error {
+	cmd := exec.Command(c.Config.Command, c.Config.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range c.Config.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	c.cmd = cmd
+	c.stdin = stdin
+	c.pending = map[string]chan *mcpClientMessage{}
+	exited := make(chan struct{})
+	c.exited = exited
+	go func() {
+		c.readStdio(stdout)
+		_ = cmd.Wait()
+		close(exited)
+	}()
+	return nil
+}
+
+// readStdio routes responses to their request. Lines that are not JSON-RPC, e.g. logs
+// of a server writing to stdout, are skipped.
+func (c *MCPClient) readStdio(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), mcpStdioMaxMessage)
+	for scanner.Scan() {
+		var msg mcpClientMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.JSONRPC != "2.0" {
+			continue
+		}
+		if msg.Method != "" {
+			if len(msg.ID) > 0 {
+				c.answerStdio(&msg)
+			}
+			continue
+		}
+		c.ioMu.Lock()
+		ch := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.ioMu.Unlock()
+		if ch != nil {
+			ch <- &msg
+		}
+	}
+
+	c.ioMu.Lock()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.pending = nil
+	c.ioMu.Unlock()
+}
+
+// answerStdio answers the requests of the server, only ping is supported.
+func (c *MCPClient) answerStdio(msg *mcpClientMessage) {
+	resp := mcpJSONRPCResponse{JSONRPC: "2.0", ID: msg.ID, Result: map[string]any{}}
+	if msg.Method != "ping" {
+		resp = mcpError(msg.ID, -32601, "method not found: "+msg.Method)
+	}
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	_, _ = c.stdin.Write(append(buf, '\n'))
+}
+
+func (c *MCPClient) writeStdio(ctx context.Context, buf []byte, id string) (*mcpClientMessage, error) {
+	var ch chan *mcpClientMessage
+	c.ioMu.Lock()
+	if c.pending == nil {
+		c.ioMu.Unlock()
+		return nil, errMCPClientClosed
+	}
+	if id != "" {
+		ch = make(chan *mcpClientMessage, 1)
+		c.pending[id] = ch
+	}
+	_, err := c.stdin.Write(append(buf, '\n'))
+	c.ioMu.Unlock()
+	if err != nil || ch == nil {
+		return nil, err
+	}
+
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, errMCPClientClosed
+		}
+		return msg, nil
+	case <-ctx.Done():
+		c.ioMu.Lock()
+		if c.pending != nil {
+			delete(c.pending, id)
+		}
+		c.ioMu.Unlock()
+		// tell the server to stop working on it
+		_, _ = c.send(context.Background(), "notifications/cancelled", map[string]any{"requestId": json.RawMessage(id), "reason": ctx.Err().Error()}, false)
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,107 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const mcpMaxCompletionValues = 100
+
+// MCPCompleteFunc suggests values for an argument of a prompt or a resource template,
+// value is what the user typed so far and arguments are the values already resolved.
+type MCPCompleteFunc func(r *Runtime, argument, value string, arguments map[string]string) ([]string, error)
+
+type mcpCompleteParams struct {
+	Ref struct {
+		Type string `json:"type"` // ref/prompt or ref/resource
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	} `json:"ref"`
+	Argument struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"argument"`
+	Context struct {
+		Arguments map[string]string `json:"arguments"`
+	} `json:"context"`
+}
+
+// mcpComplete handles completion/complete. Values come from Option.MCPComplete, or from the
+// enum of the argument in the input schema (enum and validate:"oneof" tags) filtered by prefix.
+func mcpComplete(s *Server, r *Runtime, params json.RawMessage) (any, error) {
+	var p mcpCompleteParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	var opt *Option
+	key := p.Argument.Name
+	switch p.Ref.Type {
+	case "ref/prompt":
+		opt = findMCPOption(s, "prompt", p.Ref.Name)
+	case "ref/resource":
+		name, _, _, ok := mcpSplitFunctionURI(p.Ref.URI)
+		if ok {
+			opt = findMCPOption(s, "resource", name)
+		}
+		if opt != nil {
+			// template variables are tag names, the schema uses the JSON names
+			t := newMCPURITemplate(opt)
+			for _, v := range append(t.path, t.query...) {
+				if v.name == p.Argument.Name {
+					key = v.key
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported completion reference: %s", p.Ref.Type)
+	}
+	if opt == nil || !mcpAllowed(r, opt) {
+		// markdown prompts and local resources have no arguments
+		return mcpCompletion(nil), nil
+	}
+
+	if opt.MCPComplete != nil {
+		values, err := opt.MCPComplete(r, p.Argument.Name, p.Argument.Value, p.Context.Arguments)
+		if err != nil {
+			mcpLogError(r, "completion", mcpFunctionName(opt), "complete", err)
+			return nil, err
+		}
+		return mcpCompletion(values), nil
+	}
+
+	schema, err := mcpInputSchemaMap(opt)
+	if err != nil {
+		return nil, err
+	}
+	props, _ := schema["properties"].(map[string]any)
+	prop, _ := props[key].(map[string]any)
+	enum, _ := prop["enum"].([]any)
+	values := []string{}
+	prefix := strings.ToLower(p.Argument.Value)
+	for _, v := range enum {
+		value := fmt.Sprint(v)
+		if strings.HasPrefix(strings.ToLower(value), prefix) {
+			values = append(values, value)
+		}
+	}
+	return mcpCompletion(values), nil
+}
+
+func mcpCompletion(values []string) map[string]any {
+	total := len(values)
+	if values == nil {
+		values = []string{}
+	}
+	if total > mcpMaxCompletionValues {
+		values = values[:mcpMaxCompletionValues]
+	}
+	return map[string]any{
+		"completion": map[string]any{
+			"values":  values,
+			"total":   total,
+			"hasMore": total > len(values),
+		},
+	}
+}
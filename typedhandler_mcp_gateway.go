@@ -0,0 +1,313 @@
+package allino
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wh-kuromai/jsonino"
+	"go.uber.org/zap"
+)
+
+// MCPTool is a tool of a remote MCP server as a Function, for NewAI, Option.Tools or the
+// MCP gateway. The name, description and schema come from tools/list on first use.
+type MCPTool struct {
+	Client *MCPClient
+	Tool   string // the name on the remote server
+
+	mu      sync.Mutex
+	options *Option
+	info    *mcpRemoteToolInfo
+}
+
+// Tool returns the remote tool name, it is listed when its schema or description is needed.
+func (c *MCPClient) Tool(name string) *MCPTool {
+	v, ok := c.tools.Load(name)
+	if ok {
+		return v.(*MCPTool)
+	}
+	t := &MCPTool{Client: c, Tool: name}
+	t.options = t.newOptions()
+	v, _ = c.tools.LoadOrStore(name, t)
+	return v.(*MCPTool)
+}
+
+func (t *MCPTool) newOptions() *Option {
+	opt := &Option{
+		Name:        t.Client.Config.Prefix + t.Tool,
+		ContentType: JSON,
+		Method:      "POST",
+		Package:     "mcp/" + t.Client.Name,
+		inputType:   reflect.TypeOf(map[string]any{}),
+		outputType:  reflect.TypeOf((*any)(nil)).Elem(),
+		errorType:   reflect.TypeOf((*error)(nil)).Elem(),
+		eiserror:    true,
+	}
+	opt.caller = t.callJSON
+	return opt
+}
+
+func (t *MCPTool) setInfo(info mcpRemoteToolInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.info = &info
+	t.options.Name = t.Client.Config.Prefix + info.Name
+	t.options.Summary = info.Title
+	t.options.Description = info.Description
+}
+
+// load lists the tools of the server once.
+func (t *MCPTool) load(ctx context.Context) (*mcpRemoteToolInfo, error) {
+	t.mu.Lock()
+	info := t.info
+	t.mu.Unlock()
+	if info != nil {
+		return info, nil
+	}
+	infos, err := t.Client.listTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.Name == t.Tool {
+			t.setInfo(info)
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("mcp tool not found: %s/%s", t.Client.Name, t.Tool)
+}
+
+// Options has the name and description once the tool is listed, InputSchema lists it.
+func (t *MCPTool) Options() *Option {
+	return t.options
+}
+
+func (t *MCPTool) Copy() Function {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	opt := *t.options
+	c := &MCPTool{Client: t.Client, Tool: t.Tool, options: &opt, info: t.info}
+	c.options.caller = c.callJSON
+	return c
+}
+
+// HandleRequest answers the JSON arguments of the body, remote tools have no route by default.
+func (t *MCPTool) HandleRequest(r *Runtime) {
+	h := contentTypeHandlerMap[JSON]
+	input, err := t.UnmarshalInput(r.fiber.Body())
+	if err != nil {
+		h.ErrorHandler(r, t.options, err)
+		return
+	}
+	output, err := t.Handlefunc(r, input)
+	if err != nil {
+		h.ErrorHandler(r, t.options, err)
+		return
+	}
+	h.ResponseHandler(r, t.options, output)
+}
+
+// Handlefunc calls the remote tool. The output is the structured content, the text of text
+// only results, or the content blocks. A tool error is returned as an error.
+func (t *MCPTool) Handlefunc(r *Runtime, input any) (output any, err error) {
+	result, err := t.Client.CallTool(r.Context(), t.Tool, input)
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		return nil, errors.New(mcpContentText(result.Content))
+	}
+	return mcpToolResultOutput(result), nil
+}
+
+func mcpToolResultOutput(result *MCPToolResult) any {
+	if result.StructuredContent != nil {
+		return result.StructuredContent
+	}
+	for _, c := range result.Content {
+		if c.Type != "text" {
+			return MCPContents(result.Content)
+		}
+	}
+	return mcpContentText(result.Content)
+}
+
+func (t *MCPTool) callJSON(r *Runtime, injson []byte, infunc func(input any) error) (outjson []byte, errjson []byte, syserr error) {
+	input, err := t.UnmarshalInput(injson)
+	if err != nil {
+		return nil, nil, ErrJobInputDecodeFailed.With(err)
+	}
+	result, err := t.Client.CallTool(r.Context(), t.Tool, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result.IsError {
+		errjson, err = json.Marshal(mcpContentText(result.Content))
+		return nil, errjson, err
+	}
+	outjson, err = json.Marshal(mcpToolResultOutput(result))
+	return outjson, nil, err
+}
+
+func mcpContentText(content []MCPContent) string {
+	texts := []string{}
+	for _, c := range content {
+		if c.Type == "text" {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (t *MCPTool) InputSchema() (*jsonino.Schema, error) {
+	info, err := t.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return mcpRemoteSchema(info.InputSchema)
+}
+
+func (t *MCPTool) OutputSchema() (*jsonino.Schema, error) {
+	info, err := t.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(info.OutputSchema) == 0 {
+		return nil, errors.New("no outputSchema")
+	}
+	return mcpRemoteSchema(info.OutputSchema)
+}
+
+func (t *MCPTool) ErrorSchema() (*jsonino.Schema, error) {
+	return nil, errors.New("no errorType")
+}
+
+func mcpRemoteSchema(buf json.RawMessage) (*jsonino.Schema, error) {
+	var schema jsonino.Schema
+	if err := json.Unmarshal(buf, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (t *MCPTool) UnmarshalInput(buf []byte) (input any, err error) {
+	args := map[string]any{}
+	if len(buf) > 0 && string(buf) != "null" {
+		if err := json.Unmarshal(buf, &args); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func (t *MCPTool) UnmarshalOutput(buf []byte) (output any, err error) {
+	err = json.Unmarshal(buf, &output)
+	return output, err
+}
+
+func (t *MCPTool) UnmarshalError(buf []byte) (outerr error, err error) {
+	var msg string
+	if err := json.Unmarshal(buf, &msg); err != nil {
+		return nil, err
+	}
+	return errors.New(msg), nil
+}
+
+// gateway
+
+// registerMCPGateways lists the tools of the mcp.servers marked gateway and adds them to the
+// tools of s. A server that cannot be reached is logged and skipped.
+func registerMCPGateways(s *Server) bool {
+	servers := mcpConfig().Servers
+	names := make([]string, 0, len(servers))
+	for name, config := range servers {
+		if config.Gateway {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	registered := false
+	for _, name := range names {
+		client := MCPServer(name)
+		ctx, cancel := context.WithTimeout(s.appctx, client.timeout())
+		tools, err := client.ListTools(ctx)
+		cancel()
+		if err != nil {
+			s.Logger.Error("mcp gateway error", zap.String("server", name), zap.Error(err))
+			continue
+		}
+		for _, tool := range tools {
+			gt := tool.Copy().(*MCPTool)
+			gt.options.MCP = "tool"
+			gt.options.Auth = servers[name].Auth
+			gt.options.ACLResource = servers[name].ACLResource
+			gt.options.ACLAction = servers[name].ACLAction
+			gt.options.mcpTool = gt
+			s.TypedHandle(gt)
+			registered = true
+		}
+		if !s.Config.Log.Silent {
+			s.Logger.Info("mcp gateway", zap.String("server", name), zap.Int("tools", len(tools)))
+		}
+	}
+	return registered
+}
+
+func closeMCPGateways() {
+	for name, config := range mcpConfig().Servers {
+		if config.Gateway {
+			MCPServer(name).Close()
+		}
+	}
+}
+
+// mcpGatewayToolEntry is the tools/list entry of a remote tool, as listed by its server.
+func mcpGatewayToolEntry(opt *Option) map[string]any {
+	t := opt.mcpTool
+	t.mu.Lock()
+	info := t.info
+	t.mu.Unlock()
+	tool := map[string]any{
+		"name":        mcpFunctionName(opt),
+		"description": info.Description,
+	}
+	for key, raw := range map[string]json.RawMessage{
+		"inputSchema":  info.InputSchema,
+		"outputSchema": info.OutputSchema,
+		"annotations":  info.Annotations,
+	} {
+		var v any
+		if len(raw) > 0 && json.Unmarshal(raw, &v) == nil && v != nil {
+			tool[key] = v
+		}
+	}
+	if info.Title != "" {
+		tool["title"] = info.Title
+	}
+	return tool
+}
+
+// mcpGatewayToolCall forwards tools/call, the remote result is answered as is.
+// Option.Auth, ACL and middleware apply as for the functions of the server.
+func mcpGatewayToolCall(r *Runtime, opt *Option, arguments json.RawMessage) (any, error) {
+	t := opt.mcpTool
+	input, err := t.UnmarshalInput(arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.enforceAuth(opt); err != nil {
+		return nil, err
+	}
+	if err := r.enforceACL(opt, input); err != nil {
+		return nil, err
+	}
+	return runMiddleware(r, opt, middlewareChain(r, opt), input, func() (any, error) {
+		return t.Client.callTool(r.Context(), t.Tool, input)
+	})
+}
@@ -0,0 +1,119 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// mcpLogLevels maps the syslog levels of MCP logging/setLevel to zap.
+var mcpLogLevels = map[string]zapcore.Level{
+	"debug":     zapcore.DebugLevel,
+	"info":      zapcore.InfoLevel,
+	"notice":    zapcore.InfoLevel,
+	"warning":   zapcore.WarnLevel,
+	"error":     zapcore.ErrorLevel,
+	"critical":  zapcore.DPanicLevel,
+	"alert":     zapcore.DPanicLevel,
+	"emergency": zapcore.DPanicLevel,
+}
+
+func mcpLogLevelName(level zapcore.Level) string {
+	switch level {
+	case zapcore.DebugLevel:
+		return "debug"
+	case zapcore.InfoLevel:
+		return "info"
+	case zapcore.WarnLevel:
+		return "warning"
+	case zapcore.ErrorLevel:
+		return "error"
+	case zapcore.FatalLevel:
+		return "emergency"
+	}
+	return "critical"
+}
+
+func (m *mcpSession) setLogLevel(level zapcore.Level) {
+	m.mu.Lock()
+	m.logging = true
+	m.logLevel = level
+	m.mu.Unlock()
+}
+
+func (m *mcpSession) clientLogLevel() (zapcore.Level, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.logLevel, m.logging
+}
+
+// mcpSetLogLevel handles logging/setLevel, the log of later requests of the session
+// at this level or above is sent to the client as notifications/message.
+func mcpSetLogLevel(r *Runtime, params json.RawMessage) (any, error) {
+	var p struct {
+		Level string `json:"level"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	level, ok := mcpLogLevels[p.Level]
+	if !ok {
+		return nil, fmt.Errorf("unsupported log level: %s", p.Level)
+	}
+	session := r.memo.mcpSession
+	if session == nil {
+		return nil, fmt.Errorf("logging requires an MCP session")
+	}
+	session.setLogLevel(level)
+	return map[string]any{}, nil
+}
+
+// mcpLogCore is a zap core writing the entries of a request as MCP log notifications.
+type mcpLogCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+	notify func(params map[string]any)
+}
+
+func (c *mcpLogCore) With(fields []zapcore.Field) zapcore.Core {
+	return &mcpLogCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(append([]zapcore.Field(nil), c.fields...), fields...),
+		notify:       c.notify,
+	}
+}
+
+func (c *mcpLogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *mcpLogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	data := enc.Fields
+	data["message"] = ent.Message
+
+	logger := ent.LoggerName
+	if logger == "" {
+		logger = "allino"
+	}
+	c.notify(map[string]any{
+		"level":  mcpLogLevelName(ent.Level),
+		"logger": logger,
+		"data":   data,
+	})
+	return nil
+}
+
+func (c *mcpLogCore) Sync() error {
+	return nil
+}
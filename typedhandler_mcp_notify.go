@@ -0,0 +1,241 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultMCPNotifyChannel = "allino:mcp:notify"
+const defaultMCPWatchInterval = 2 * time.Second
+
+// mcpNotifyMessage is a server-initiated notification relayed between nodes over Redis.
+type mcpNotifyMessage struct {
+	Origin string          `json:"origin"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	URI    string          `json:"uri,omitempty"` // only sessions subscribed to URI receive it
+}
+
+// NotifyMCP sends a JSON-RPC notification to every MCP session of s that has a GET stream,
+// e.g. "notifications/tools/list_changed". With Redis, sessions on the other nodes receive it too.
+func (s *Server) NotifyMCP(method string, params any) {
+	publishMCPNotification(s, method, params, "")
+}
+
+// NotifyMCPResourceChanged sends notifications/resources/updated to the MCP sessions
+// subscribed to uri with resources/subscribe, on every node when Redis is configured.
+func (s *Server) NotifyMCPResourceChanged(uri string) {
+	publishMCPNotification(s, "notifications/resources/updated", map[string]any{"uri": uri}, uri)
+}
+
+// NotifyResourceChanged tells the MCP clients subscribed to uri that the resource changed,
+// e.g. allino://users/42 after the user was updated.
+func (r *Runtime) NotifyResourceChanged(uri string) {
+	r.server.NotifyMCPResourceChanged(uri)
+}
+
+func publishMCPNotification(s *Server, method string, params any, uri string) {
+	deliverMCPNotification(s, method, params, uri)
+	if s.Redis == nil {
+		return
+	}
+	msg := mcpNotifyMessage{Origin: s.ServerID(), Method: method, URI: uri}
+	if params != nil {
+		buf, err := json.Marshal(params)
+		if err != nil {
+			return
+		}
+		msg.Params = buf
+	}
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := s.Redis.Publish(s.appctx, mcpNotifyChannel(), buf).Err(); err != nil && !s.Config.Log.Silent {
+		s.Logger.Error("mcp notify publish error", zap.String("method", method), zap.Error(err))
+	}
+}
+
+// deliverMCPNotification sends a notification to the sessions of this node.
+func deliverMCPNotification(s *Server, method string, params any, uri string) {
+	mcpSessions.Range(func(_, v any) bool {
+		session := v.(*mcpSession)
+		if session.server == s && (uri == "" || session.subscribed(uri)) {
+			session.send(mcpJSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+		}
+		return true
+	})
+}
+
+func mcpNotifyChannel() string {
+	if channel := strings.TrimSpace(mcpConfig().NotifyChannel); channel != "" {
+		return channel
+	}
+	return defaultMCPNotifyChannel
+}
+
+// subscribeMCPNotifications relays the notifications published by the other nodes.
+func subscribeMCPNotifications(s *Server) {
+	if s.Redis == nil {
+		return
+	}
+	pubsub := s.Redis.Subscribe(s.appctx, mcpNotifyChannel())
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case m, ok := <-ch:
+				if !ok {
+					return
+				}
+				var msg mcpNotifyMessage
+				if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil || msg.Origin == s.ServerID() {
+					continue
+				}
+				var params any
+				if len(msg.Params) > 0 {
+					params = msg.Params
+				}
+				deliverMCPNotification(s, msg.Method, params, msg.URI)
+			case <-s.appctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func mcpResourceSubscribe(s *Server, r *Runtime, params json.RawMessage, subscribe bool) (any, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.URI == "" {
+		return nil, fmt.Errorf("resource uri is required")
+	}
+	session := r.memo.mcpSession
+	if session == nil {
+		return nil, fmt.Errorf("resource subscriptions require an MCP session")
+	}
+	if subscribe {
+		// updates reveal that the resource changed, only readers may subscribe.
+		if err := mcpResourceAllowed(s, r, p.URI); err != nil {
+			return nil, err
+		}
+		session.subscribe(p.URI)
+	} else {
+		session.unsubscribe(p.URI)
+	}
+	return map[string]any{}, nil
+}
+
+type mcpFileStamp struct {
+	modTime int64
+	size    int64
+}
+
+// watchMCPDirs polls mcp.resourceDirs and mcp.promptDirs: added and removed files send
+// list_changed, modified resources send notifications/resources/updated to their subscribers.
+// The directories are usually deployed on every node, so notifications stay on this node.
+func watchMCPDirs(s *Server) {
+	if s.TimeWheel == nil {
+		return
+	}
+	config := mcpConfig()
+	interval := config.WatchInterval
+	if interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = defaultMCPWatchInterval
+	}
+
+	// the directories are taken once, the timer does not read the shared config.
+	resourceDirs := append([]string(nil), config.ResourceDirs...)
+	promptDirs := append([]string(nil), config.PromptDirs...)
+
+	resources, _ := mcpResourceStamps(s, resourceDirs)
+	prompts := mcpPromptStamps(s, promptDirs)
+	s.TimeWheel.Add(interval, func() bool {
+		if s.appctx.Err() != nil {
+			return false
+		}
+
+		// a failed scan keeps the previous stamps, the files are compared again on the next tick.
+		if nextResources, err := mcpResourceStamps(s, resourceDirs); err == nil {
+			listChanged := len(nextResources) != len(resources)
+			for uri, stamp := range nextResources {
+				prev, ok := resources[uri]
+				if !ok {
+					listChanged = true
+				} else if prev != stamp {
+					deliverMCPNotification(s, "notifications/resources/updated", map[string]any{"uri": uri}, uri)
+				}
+			}
+			if listChanged {
+				deliverMCPNotification(s, "notifications/resources/list_changed", nil, "")
+			}
+			resources = nextResources
+		}
+
+		nextPrompts := mcpPromptStamps(s, promptDirs)
+		if !mcpSameStamps(prompts, nextPrompts) {
+			deliverMCPNotification(s, "notifications/prompts/list_changed", nil, "")
+		}
+		prompts = nextPrompts
+		return true
+	})
+}
+
+// mcpResourceStamps maps the URI of every mounted file resource to its modification stamp.
+func mcpResourceStamps(s *Server, dirs []string) (map[string]mcpFileStamp, error) {
+	stamps := map[string]mcpFileStamp{}
+	resources, err := mcpLoadResourceDirs(s, dirs)
+	if err != nil {
+		return stamps, err
+	}
+	for _, resource := range resources {
+		if fi, err := os.Stat(resource.Path); err == nil {
+			stamps[resource.URI] = mcpFileStamp{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+		}
+	}
+	return stamps, nil
+}
+
+// mcpPromptStamps maps the path of every mounted Markdown prompt to its modification stamp,
+// a changed file may change the name or the description of the prompt.
+func mcpPromptStamps(s *Server, dirs []string) map[string]mcpFileStamp {
+	stamps := map[string]mcpFileStamp{}
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(mcpResolvePath(s, dir), func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+				return nil
+			}
+			if fi, err := d.Info(); err == nil {
+				stamps[path] = mcpFileStamp{modTime: fi.ModTime().UnixNano(), size: fi.Size()}
+			}
+			return nil
+		})
+	}
+	return stamps
+}
+
+func mcpSameStamps(a, b map[string]mcpFileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
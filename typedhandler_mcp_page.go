@@ -0,0 +1,73 @@
+package allino
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const defaultMCPPageSize = 100
+
+func mcpPageSize() int {
+	if size := mcpConfig().PageSize; size > 0 {
+		return size
+	}
+	return defaultMCPPageSize
+}
+
+// mcpCursorOffset decodes the cursor of a list request, cursors are opaque to the client.
+func mcpCursorOffset(params json.RawMessage) (int, error) {
+	var p struct {
+		Cursor string `json:"cursor"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return 0, err
+		}
+	}
+	if p.Cursor == "" {
+		return 0, nil
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %s", p.Cursor)
+	}
+	offset, err := strconv.Atoi(string(buf))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: %s", p.Cursor)
+	}
+	return offset, nil
+}
+
+func mcpCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// mcpPaginate returns one page of the list under key of a tools/list, resources/list,
+// resources/templates/list or prompts/list result, with nextCursor when more remain.
+func mcpPaginate(params json.RawMessage, key string, result any, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	offset, err := mcpCursorOffset(params)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := result.(map[string]any)
+	if !ok {
+		return result, nil
+	}
+	items, _ := out[key].([]map[string]any)
+	if offset > len(items) {
+		return nil, fmt.Errorf("invalid cursor: offset %d out of range", offset)
+	}
+	end := offset + mcpPageSize()
+	if end < len(items) {
+		out["nextCursor"] = mcpCursor(end)
+	} else {
+		end = len(items)
+	}
+	out[key] = items[offset:end]
+	return out, nil
+}
@@ -66,7 +66,7 @@ func (r *mcpRegistryView) Summary() MCPSummary {
 	return MCPSummary{
 		Registered:            registered,
 		Endpoint:              mcpEndpoint(),
-		Protocol:              "2024-11-05",
+		Protocol:              mcpProtocolVersions[0],
 		Transport:             "streamable-http",
 		ResourceScheme:        mcpResourceScheme(),
 		ResourceHost:          mcpResourceHost(),
@@ -104,7 +104,7 @@ func (r *mcpRegistryView) Resources() ([]MCPResourceInfo, error) {
 	seen := map[string]bool{}
 	for _, opt := range mcpOptions(r.server, "resource") {
 		name := mcpFunctionName(opt)
-		uri := "allino://" + name
+		uri := newMCPURITemplate(opt).String()
 		seen[uri] = true
 		out = append(out, MCPResourceInfo{
 			URI:         uri,
@@ -0,0 +1,182 @@
+package allino
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const mcpJobPollInterval = time.Second
+
+var errMCPRequestCancelled = errors.New("MCP request cancelled")
+
+// mcpRequestKey normalizes a JSON-RPC id, so 1 and "1" stay distinct but whitespace does not matter.
+func mcpRequestKey(id json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	buf, _ := json.Marshal(v)
+	return string(buf)
+}
+
+func (m *mcpSession) track(id string, cancel context.CancelCauseFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inflight == nil {
+		m.inflight = map[string]context.CancelCauseFunc{}
+	}
+	m.inflight[id] = cancel
+}
+
+func (m *mcpSession) untrack(id string) {
+	m.mu.Lock()
+	delete(m.inflight, id)
+	m.mu.Unlock()
+}
+
+func (m *mcpSession) cancel(id string) bool {
+	m.mu.Lock()
+	cancel, ok := m.inflight[id]
+	m.mu.Unlock()
+	if ok {
+		cancel(errMCPRequestCancelled)
+	}
+	return ok
+}
+
+func (m *mcpSession) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cancel := range m.inflight {
+		cancel(errMCPRequestCancelled)
+	}
+}
+
+// beginMCPRequest gives a request of a session its own context, cancelled by
+// notifications/cancelled, and forwards its log to the client after logging/setLevel.
+// The returned function restores r, r is shared by the requests of a batch.
+func beginMCPRequest(r *Runtime, req *mcpJSONRPCRequest) (context.Context, func()) {
+	session := r.memo.mcpSession
+	if session == nil || len(req.ID) == 0 || string(req.ID) == "null" {
+		return nil, nil
+	}
+	prevCtx, prevLogger, prevLoggerWith := r.memo.ctx, r.logger, r.loggerWith
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	key := mcpRequestKey(req.ID)
+	session.track(key, cancel)
+	r.memo.ctx = ctx
+
+	if level, ok := session.clientLogLevel(); ok {
+		core := &mcpLogCore{LevelEnabler: level, notify: func(params map[string]any) {
+			notifyMCPRequest(r, "notifications/message", params)
+		}}
+		r.logger = r.logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(c, core)
+		}))
+		r.loggerWith = nil
+	}
+
+	return ctx, func() {
+		session.untrack(key)
+		cancel(nil)
+		r.memo.ctx, r.logger, r.loggerWith = prevCtx, prevLogger, prevLoggerWith
+	}
+}
+
+// mcpCancelRequest handles notifications/cancelled.
+func mcpCancelRequest(r *Runtime, params json.RawMessage) (any, error) {
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if session := r.memo.mcpSession; session != nil && len(p.RequestID) > 0 {
+		if session.cancel(mcpRequestKey(p.RequestID)) {
+			mcpLogInfo(r, "mcp request cancelled", "request", string(p.RequestID))
+		}
+	}
+	return map[string]any{}, nil
+}
+
+// notifyMCPRequest sends a notification on the transport of the current request,
+// the SSE response or stdio, and on the GET stream of the session otherwise.
+func notifyMCPRequest(r *Runtime, method string, params any) {
+	msg := mcpJSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+	if r.memo.mcpSend != nil {
+		if buf, err := json.Marshal(msg); err == nil {
+			_ = r.memo.mcpSend(buf)
+		}
+		return
+	}
+	if r.memo.mcpSession != nil {
+		r.memo.mcpSession.send(msg)
+	}
+}
+
+// mcpExplicitProgressToken returns the _meta.progressToken sent by the client.
+func mcpExplicitProgressToken(params json.RawMessage) (any, bool) {
+	var p struct {
+		Meta struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Meta.ProgressToken == nil {
+		return nil, false
+	}
+	return p.Meta.ProgressToken, true
+}
+
+// mcpJobProgress counts the finished jobs started by jobid, 0 total when it started none.
+func mcpJobProgress(r *Runtime, jobid string) (progress, total int) {
+	store := r.server.JobStore()
+	if store == nil {
+		return 0, 0
+	}
+	counts, err := store.Total(r.Context(), jobid)
+	if err != nil {
+		return 0, 0
+	}
+	for _, n := range counts {
+		total += n
+	}
+	return counts["done"] + counts["error"], total
+}
+
+// waitMCPJob keeps a tools/call open until the job is done, when the client asked for progress
+// and the transport can send it. The jobs started by the job are reported as notifications/progress.
+func waitMCPJob(r *Runtime, opt *Option, jobid string, token any) (any, error) {
+	ticker := time.NewTicker(mcpJobPollInterval)
+	defer ticker.Stop()
+	last := -1
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil, context.Cause(r.Context())
+		case <-ticker.C:
+		}
+
+		output, err := pollMCPJob(r, opt, jobid)
+		var pending *JobPendingError
+		if !errors.As(err, &pending) {
+			return output, err
+		}
+		if progress, total := mcpJobProgress(r, jobid); total > 0 && progress > last {
+			last = progress
+			notifyMCPRequest(r, "notifications/progress", map[string]any{
+				"progressToken": token,
+				"progress":      progress,
+				"total":         total,
+				"message":       fmt.Sprintf("job %s: %d of %d done", jobid, progress, total),
+			})
+		}
+	}
+}
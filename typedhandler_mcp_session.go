@@ -0,0 +1,353 @@
+package allino
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	mcpSessionHeader         = "Mcp-Session-Id"
+	mcpProtocolVersionHeader = "Mcp-Protocol-Version"
+)
+
+// mcpProtocolVersions are the supported MCP revisions, latest first.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+const defaultMCPSessionTimeout = time.Hour
+
+var mcpSessions sync.Map // session id -> *mcpSession
+
+// mcpSession is a client connection created by initialize and ended by DELETE or inactivity.
+type mcpSession struct {
+	id       string
+	server   *Server
+	protocol string
+	uid      string // the user who called initialize, other users get 404
+
+	mu            sync.Mutex
+	lastSeen      time.Time
+	subscriptions map[string]bool                    // resource URIs of resources/subscribe
+	inflight      map[string]context.CancelCauseFunc // request id -> cancel, for notifications/cancelled
+	logging       bool                               // logging/setLevel was called
+	logLevel      zapcore.Level
+
+	// server-initiated messages delivered on the GET stream
+	outbox    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMCPSession(s *Server, protocol, uid string) *mcpSession {
+	session := &mcpSession{
+		id:       uuid.New().String(),
+		server:   s,
+		protocol: protocol,
+		uid:      uid,
+		lastSeen: time.Now(),
+		outbox:   make(chan []byte, 64),
+		done:     make(chan struct{}),
+	}
+	mcpSessions.Store(session.id, session)
+	return session
+}
+
+func findMCPSession(s *Server, id, uid string) *mcpSession {
+	v, ok := mcpSessions.Load(id)
+	if !ok {
+		return nil
+	}
+	session := v.(*mcpSession)
+	if session.server != s || session.uid != uid {
+		return nil
+	}
+	session.touch()
+	return session
+}
+
+func (m *mcpSession) touch() {
+	m.mu.Lock()
+	m.lastSeen = time.Now()
+	m.mu.Unlock()
+}
+
+func (m *mcpSession) idle(now time.Time, timeout time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return now.Sub(m.lastSeen) > timeout
+}
+
+func (m *mcpSession) close() {
+	m.closeOnce.Do(func() {
+		mcpSessions.Delete(m.id)
+		m.cancelAll()
+		close(m.done)
+	})
+}
+
+// send queues a message for the GET stream, it is dropped when the client does not read.
+func (m *mcpSession) send(msg any) bool {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return false
+	}
+	select {
+	case m.outbox <- buf:
+		return true
+	case <-m.done:
+		return false
+	default:
+		return false
+	}
+}
+
+func (m *mcpSession) subscribe(uri string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subscriptions == nil {
+		m.subscriptions = map[string]bool{}
+	}
+	m.subscriptions[uri] = true
+}
+
+func (m *mcpSession) unsubscribe(uri string) {
+	m.mu.Lock()
+	delete(m.subscriptions, uri)
+	m.mu.Unlock()
+}
+
+func (m *mcpSession) subscribed(uri string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.subscriptions[uri]
+}
+
+func mcpSessionTimeout() time.Duration {
+	if timeout := mcpConfig().SessionTimeout; timeout > 0 {
+		return timeout
+	}
+	return defaultMCPSessionTimeout
+}
+
+func reapMCPSessions(s *Server) {
+	if s.TimeWheel == nil {
+		return
+	}
+	s.TimeWheel.Add(time.Minute, func() bool {
+		now := time.Now()
+		timeout := mcpSessionTimeout()
+		mcpSessions.Range(func(_, v any) bool {
+			session := v.(*mcpSession)
+			if session.server == s && session.idle(now, timeout) {
+				session.close()
+			}
+			return true
+		})
+		return true
+	})
+}
+
+func supportedMCPProtocol(version string) bool {
+	for _, v := range mcpProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateMCPProtocol returns the version requested by initialize when supported,
+// the latest supported version otherwise.
+func negotiateMCPProtocol(params json.RawMessage) string {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &p)
+	if supportedMCPProtocol(p.ProtocolVersion) {
+		return p.ProtocolVersion
+	}
+	return mcpProtocolVersions[0]
+}
+
+// mcpSessionUser is the uid a session belongs to, "" without login.
+func mcpSessionUser(r *Runtime) string {
+	uid, _, _, err := r.User()
+	if err != nil {
+		return ""
+	}
+	return uid
+}
+
+// mcpRequestSession validates the Mcp-Session-Id and Mcp-Protocol-Version headers.
+// Requests without a session id are stateless, the session of another user is not found.
+func mcpRequestSession(s *Server, r *Runtime, c *fiber.Ctx) (session *mcpSession, status int, msg string) {
+	if version := c.Get(mcpProtocolVersionHeader); version != "" && !supportedMCPProtocol(version) {
+		return nil, http.StatusBadRequest, "unsupported MCP protocol version: " + version
+	}
+	id := c.Get(mcpSessionHeader)
+	if id == "" {
+		return nil, 0, ""
+	}
+	session = findMCPSession(s, id, mcpSessionUser(r))
+	if session == nil {
+		return nil, http.StatusNotFound, "MCP session not found"
+	}
+	return session, 0, ""
+}
+
+// mcpSessionRequired reports whether req is rejected without Mcp-Session-Id: initialize
+// creates the session and later requests must send it, unless mcp.stateless is set.
+func mcpSessionRequired(r *Runtime, req *mcpJSONRPCRequest) bool {
+	return r.memo.mcpSession == nil && req.Method != "initialize" && !mcpConfig().Stateless
+}
+
+// isMCPBatch reports whether body is a JSON-RPC batch.
+func isMCPBatch(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) > 0 && body[0] == '['
+}
+
+// handleMCPBatch answers every request of a batch in order, notifications and client
+// responses have no entry in the reply.
+func handleMCPBatch(s *Server, r *Runtime, c *fiber.Ctx, body []byte) error {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32700, err.Error()))
+	}
+	if len(batch) == 0 {
+		return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32600, "empty batch"))
+	}
+	for _, raw := range batch {
+		var req mcpJSONRPCRequest
+		if json.Unmarshal(raw, &req) == nil && mcpSessionRequired(r, &req) {
+			return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32000, mcpSessionHeader+" is required"))
+		}
+	}
+	responses := []mcpJSONRPCResponse{}
+	for _, raw := range batch {
+		var req mcpJSONRPCRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, mcpError(nil, -32700, err.Error()))
+			continue
+		}
+		if resp := handleMCPMessage(s, r, c, &req); resp != nil {
+			responses = append(responses, *resp)
+		}
+	}
+	if len(responses) == 0 {
+		return c.SendStatus(http.StatusAccepted)
+	}
+	return c.JSON(responses)
+}
+
+// handleMCPMessage dispatches one JSON-RPC message, it returns nil for notifications and
+// for responses to server-initiated requests.
+func handleMCPMessage(s *Server, r *Runtime, c *fiber.Ctx, req *mcpJSONRPCRequest) *mcpJSONRPCResponse {
+	hasID := len(req.ID) > 0 && string(req.ID) != "null"
+	if req.Method == "" {
+		return nil
+	}
+	if !hasID {
+		req.ID = []byte("null")
+	}
+
+	if req.Method == "initialize" && !mcpConfig().Stateless {
+		session := newMCPSession(s, negotiateMCPProtocol(req.Params), mcpSessionUser(r))
+		c.Set(mcpSessionHeader, session.id)
+	}
+
+	result, err := dispatchMCPRequest(s, r, req)
+	if !hasID {
+		return nil
+	}
+	if err != nil {
+		mcpLogError(r, "request", req.Method, "dispatch", err)
+		resp := mcpError(req.ID, -32603, err.Error())
+		return &resp
+	}
+	return &mcpJSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}
+}
+
+// handleMCPStream is the GET stream of a session, it relays server-initiated messages until
+// the session ends or the client disconnects.
+func handleMCPStream(s *Server, c *fiber.Ctx, session *mcpSession) error {
+	c.Set("Content-Type", EventStream)
+	c.Set("Cache-Control", "no-cache")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		keepalive := time.NewTicker(30 * time.Second)
+		defer keepalive.Stop()
+		for {
+			select {
+			case buf := <-session.outbox:
+				if err := writeSSE(w, "message", buf); err != nil {
+					return
+				}
+			case <-keepalive.C:
+				w.WriteString(": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			case <-session.done:
+				return
+			case <-s.appctx.Done():
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func handleMCPGet(s *Server, c *fiber.Ctx) error {
+	r := NewRuntime(s, c)
+	if ok, err := mcpAuthenticate(r, c); !ok {
+		return err
+	}
+	if !acceptsEventStream(c.Get("Accept")) {
+		return c.JSON(map[string]any{
+			"name":      s.Config.AppName,
+			"endpoint":  mcpEndpoint(),
+			"protocol":  mcpProtocolVersions[0],
+			"protocols": mcpProtocolVersions,
+			"transport": "streamable-http",
+		})
+	}
+	session, status, msg := mcpRequestSession(s, r, c)
+	if status != 0 {
+		return c.Status(status).JSON(mcpError(nil, -32000, msg))
+	}
+	if session == nil {
+		return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32000, mcpSessionHeader+" is required"))
+	}
+	return handleMCPStream(s, c, session)
+}
+
+func handleMCPDelete(s *Server, c *fiber.Ctx) error {
+	r := NewRuntime(s, c)
+	if ok, err := mcpAuthenticate(r, c); !ok {
+		return err
+	}
+	if strings.TrimSpace(c.Get(mcpSessionHeader)) == "" {
+		return c.Status(http.StatusBadRequest).JSON(mcpError(nil, -32000, mcpSessionHeader+" is required"))
+	}
+	session, status, msg := mcpRequestSession(s, r, c)
+	if status != 0 {
+		return c.Status(status).JSON(mcpError(nil, -32000, msg))
+	}
+	session.close()
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package allino
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+const mcpStdioMaxMessage = 16 * 1024 * 1024
+
+// ServeMCPStdio serves MCP over newline-delimited JSON-RPC, reading requests from in and
+// writing responses and server-initiated messages to out until in is closed. Requests are
+// handled concurrently, so a long-running tool call does not block the others.
+//
+// Calls over stdio have no HTTP request, functions that require a user are not listed.
+func (s *Server) ServeMCPStdio(in io.Reader, out io.Writer) error {
+	var mu sync.Mutex
+	writeLine := func(buf []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := out.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+		return nil
+	}
+	write := func(msg any) {
+		buf, err := json.Marshal(msg)
+		if err == nil {
+			_ = writeLine(buf)
+		}
+	}
+
+	// NotifyMCP reaches the stdio client through a session like a GET stream
+	session := newMCPSession(s, mcpProtocolVersions[0], "")
+	defer session.close()
+	go func() {
+		for {
+			select {
+			case buf := <-session.outbox:
+				_ = writeLine(buf)
+			case <-session.done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), mcpStdioMaxMessage)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		line = append([]byte(nil), line...)
+		session.touch()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp := handleMCPStdioLine(s, session, line, writeLine); resp != nil {
+				write(resp)
+			}
+		}()
+	}
+	wg.Wait()
+	return scanner.Err()
+}
+
+// handleMCPStdioLine answers one line, a request or a batch. It returns nil when nothing
+// needs an answer.
+func handleMCPStdioLine(s *Server, session *mcpSession, line []byte, send func(buf []byte) error) any {
+	if !isMCPBatch(line) {
+		var req mcpJSONRPCRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return mcpError(nil, -32700, err.Error())
+		}
+		if resp := handleMCPStdioMessage(s, session, &req, send); resp != nil {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		return mcpError(nil, -32700, err.Error())
+	}
+	responses := []mcpJSONRPCResponse{}
+	for _, raw := range batch {
+		var req mcpJSONRPCRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, mcpError(nil, -32700, err.Error()))
+			continue
+		}
+		if resp := handleMCPStdioMessage(s, session, &req, send); resp != nil {
+			responses = append(responses, *resp)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+func handleMCPStdioMessage(s *Server, session *mcpSession, req *mcpJSONRPCRequest, send func(buf []byte) error) *mcpJSONRPCResponse {
+	hasID := len(req.ID) > 0 && string(req.ID) != "null"
+	if req.Method == "" {
+		return nil
+	}
+	if !hasID {
+		req.ID = []byte("null")
+	}
+
+	r := NewRuntime(s, nil)
+	defer r.do_defer()
+	r.cache.req_type = REQUEST_CLI
+	r.memo.mcpSession = session
+	r.memo.mcpSend = send
+	if hasID {
+		r.memo.streamSink = mcpProgressSink(mcpProgressToken(req.Params, req.ID), send)
+	}
+
+	result, err := dispatchMCPRequest(s, r, req)
+	if !hasID || errors.Is(err, errMCPRequestCancelled) {
+		// no response for a request cancelled by the client
+		return nil
+	}
+	if err != nil {
+		mcpLogError(r, "request", req.Method, "dispatch", err)
+		resp := mcpError(req.ID, -32603, err.Error())
+		return &resp
+	}
+	return &mcpJSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	}
+}
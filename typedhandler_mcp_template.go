@@ -0,0 +1,216 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const mcpFunctionResourcePrefix = "allino://"
+
+// mcpURIVar is a path or query tagged input field bound from a resource URI.
+type mcpURIVar struct {
+	name  string // tag name, the template variable
+	key   string // JSON name of the field
+	field reflect.StructField
+}
+
+// mcpURITemplate is the RFC 6570 URI template of a resource function,
+// e.g. allino://users/{id}{?expand} for path:"id" and query:"expand" fields.
+type mcpURITemplate struct {
+	name  string
+	path  []mcpURIVar
+	query []mcpURIVar
+}
+
+func newMCPURITemplate(opt *Option) *mcpURITemplate {
+	t := &mcpURITemplate{name: mcpFunctionName(opt)}
+	it := opt.InputType()
+	for it != nil && it.Kind() == reflect.Ptr {
+		it = it.Elem()
+	}
+	if it != nil && it.Kind() == reflect.Struct {
+		t.addFields(it)
+	}
+	return t
+}
+
+func (t *mcpURITemplate) addFields(st reflect.Type) {
+	for i := 0; i < st.NumField(); i++ {
+		field := st.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			t.addFields(field.Type)
+			continue
+		}
+		key := mcpJSONKey(field)
+		if field.PkgPath != "" || key == "" {
+			continue
+		}
+		if name := field.Tag.Get("path"); name != "" {
+			t.path = append(t.path, mcpURIVar{name: pathParamName(name), key: key, field: field})
+		} else if name := field.Tag.Get("query"); name != "" {
+			t.query = append(t.query, mcpURIVar{name: name, key: key, field: field})
+		}
+	}
+}
+
+// mcpJSONKey is the name encoding/json decodes the field from, "" when it is skipped.
+func mcpJSONKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// isTemplate reports whether the URI has variables.
+func (t *mcpURITemplate) isTemplate() bool {
+	return len(t.path) > 0 || len(t.query) > 0
+}
+
+func (t *mcpURITemplate) String() string {
+	var sb strings.Builder
+	sb.WriteString(mcpFunctionResourcePrefix + t.name)
+	for _, v := range t.path {
+		sb.WriteString("/{" + v.name + "}")
+	}
+	if len(t.query) > 0 {
+		names := make([]string, len(t.query))
+		for i, v := range t.query {
+			names[i] = v.name
+		}
+		sb.WriteString("{?" + strings.Join(names, ",") + "}")
+	}
+	return sb.String()
+}
+
+// mcpSplitFunctionURI splits allino://name/a/b?q=1 into the function name, the path
+// segments after the name and the query.
+func mcpSplitFunctionURI(uri string) (name string, segments []string, query url.Values, ok bool) {
+	rest, found := strings.CutPrefix(uri, mcpFunctionResourcePrefix)
+	if !found {
+		return "", nil, nil, false
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	parts := strings.Split(rest, "/")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, nil, false
+	}
+	return parts[0], parts[1:], query, true
+}
+
+// arguments binds the path segments and the query of a resource URI to the JSON
+// arguments of the function input.
+func (t *mcpURITemplate) arguments(segments []string, query url.Values) (map[string]any, error) {
+	if len(segments) != len(t.path) {
+		return nil, fmt.Errorf("MCP resource URI does not match %s", t)
+	}
+	args := map[string]any{}
+	for i, v := range t.path {
+		raw, err := url.PathUnescape(segments[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := mcpURIValue(v.field.Type, []string{raw})
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for {%s}: %w", v.name, err)
+		}
+		args[v.key] = value
+	}
+	for _, v := range t.query {
+		values, ok := query[v.name]
+		if !ok {
+			continue
+		}
+		value, err := mcpURIValue(v.field.Type, values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for {?%s}: %w", v.name, err)
+		}
+		args[v.key] = value
+	}
+	return args, nil
+}
+
+// mcpURIValue converts URI strings to a JSON value decodable into t.
+func mcpURIValue(t reflect.Type, values []string) (any, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		out := make([]any, 0, len(values))
+		for _, raw := range values {
+			for _, item := range strings.Split(raw, ",") {
+				v, err := mcpURIValue(t.Elem(), []string{item})
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+		}
+		return out, nil
+	}
+
+	raw := ""
+	if len(values) > 0 {
+		raw = values[0]
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	case reflect.String:
+		return raw, nil
+	}
+	// other types decode themselves from JSON, e.g. time.Time
+	var v any
+	if json.Unmarshal([]byte(raw), &v) == nil {
+		return v, nil
+	}
+	return raw, nil
+}
+
+// mcpResourceArguments merges the URI variables into the arguments of resources/read.
+func mcpResourceArguments(args json.RawMessage, uriArgs map[string]any) (json.RawMessage, error) {
+	if len(uriArgs) == 0 {
+		return args, nil
+	}
+	merged := map[string]any{}
+	if len(args) > 0 && string(args) != "null" {
+		if err := json.Unmarshal(args, &merged); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range uriArgs {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+func mcpResourceTemplatesList(s *Server, r *Runtime) (any, error) {
+	templates := []map[string]any{}
+	for _, opt := range mcpListOptions(s, r, "resource") {
+		t := newMCPURITemplate(opt)
+		if !t.isTemplate() {
+			continue
+		}
+		templates = append(templates, map[string]any{
+			"uriTemplate": t.String(),
+			"name":        t.name,
+			"description": opt.Description,
+			"mimeType":    opt.ContentType,
+		})
+	}
+	return map[string]any{"resourceTemplates": templates}, nil
+}
@@ -0,0 +1,223 @@
+package allino
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// MCPToolAnnotations are the behavior hints of an MCP tool. Nil hints default from
+// Option.Method and Option.JobMode, see mcpToolAnnotations.
+type MCPToolAnnotations struct {
+	Title           string // defaults to Option.Summary
+	ReadOnlyHint    *bool  // the tool does not modify its environment
+	DestructiveHint *bool  // the tool may perform destructive updates
+	IdempotentHint  *bool  // calling the tool again with the same arguments has no additional effect
+	OpenWorldHint   *bool  // the tool interacts with external entities
+}
+
+// mcpToolAnnotations returns the annotations of tools/list, nil when there is no hint.
+//
+// Tools with an HTTP route follow its method: GET and HEAD are read-only, PUT and DELETE are
+// idempotent and DELETE is destructive. Method defaults to GET, so tools without a Path get no
+// hint from it. Job modes returning the stored output for the same input (cache, dedupe, once, memoized)
+// are idempotent.
+func mcpToolAnnotations(opt *Option) map[string]any {
+	var a MCPToolAnnotations
+	if opt.MCPAnnotations != nil {
+		a = *opt.MCPAnnotations
+	}
+	yes, no := true, false
+	method := ""
+	if opt.Path != "" {
+		method = strings.ToUpper(opt.Method)
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		if a.ReadOnlyHint == nil {
+			a.ReadOnlyHint = &yes
+		}
+	case http.MethodPut:
+		if a.IdempotentHint == nil {
+			a.IdempotentHint = &yes
+		}
+	case http.MethodDelete:
+		if a.IdempotentHint == nil {
+			a.IdempotentHint = &yes
+		}
+		if a.DestructiveHint == nil {
+			a.DestructiveHint = &yes
+		}
+	}
+	switch opt.JobMode {
+	case JOBMODE_CACHE, JOBMODE_DEDUPE, JOBMODE_ONCE, JOBMODE_MEMOIZED:
+		if a.IdempotentHint == nil {
+			a.IdempotentHint = &yes
+		}
+	}
+	if a.ReadOnlyHint != nil && *a.ReadOnlyHint {
+		// a read-only tool destroys nothing
+		if a.DestructiveHint == nil {
+			a.DestructiveHint = &no
+		}
+	}
+	if a.Title == "" {
+		a.Title = opt.Summary
+	}
+
+	out := map[string]any{}
+	if a.Title != "" {
+		out["title"] = a.Title
+	}
+	for name, hint := range map[string]*bool{
+		"readOnlyHint":    a.ReadOnlyHint,
+		"destructiveHint": a.DestructiveHint,
+		"idempotentHint":  a.IdempotentHint,
+		"openWorldHint":   a.OpenWorldHint,
+	} {
+		if hint != nil {
+			out[name] = *hint
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// MCPContent is a content block of an MCP tool result, built with MCPText, MCPImage,
+// MCPAudio, MCPResource or MCPResourceLink.
+type MCPContent struct {
+	Type        string               `json:"type"` // text, image, audio, resource or resource_link
+	Text        string               `json:"text,omitempty"`
+	Data        string               `json:"data,omitempty"` // base64 image or audio
+	MimeType    string               `json:"mimeType,omitempty"`
+	Resource    *MCPEmbeddedResource `json:"resource,omitempty"`
+	URI         string               `json:"uri,omitempty"` // resource_link
+	Name        string               `json:"name,omitempty"`
+	Description string               `json:"description,omitempty"`
+}
+
+// MCPEmbeddedResource is the resource of a "resource" content block, Text for UTF-8 and Blob otherwise.
+type MCPEmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// MCPContentProvider is implemented by tool outputs returning content blocks instead of JSON.
+type MCPContentProvider interface {
+	MCPContent() []MCPContent
+}
+
+// MCPContents is a tool output made of content blocks.
+type MCPContents []MCPContent
+
+func (c MCPContents) MCPContent() []MCPContent {
+	return c
+}
+
+func MCPText(text string) MCPContent {
+	return MCPContent{Type: "text", Text: text}
+}
+
+func MCPImage(data []byte, mimeType string) MCPContent {
+	return MCPContent{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+func MCPAudio(data []byte, mimeType string) MCPContent {
+	return MCPContent{Type: "audio", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// MCPResource embeds the content of a resource, as text when data is UTF-8.
+func MCPResource(uri, mimeType string, data []byte) MCPContent {
+	resource := &MCPEmbeddedResource{URI: uri, MimeType: mimeType}
+	if utf8.Valid(data) {
+		resource.Text = string(data)
+	} else {
+		resource.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return MCPContent{Type: "resource", Resource: resource}
+}
+
+// MCPResourceLink points to a resource the client can read with resources/read.
+func MCPResourceLink(uri, name, mimeType string) MCPContent {
+	return MCPContent{Type: "resource_link", URI: uri, Name: name, MimeType: mimeType}
+}
+
+var mcpContentProviderType = reflect.TypeOf((*MCPContentProvider)(nil)).Elem()
+
+// mcpBinaryOutput reports whether the function returns []byte with a non-JSON content type,
+// e.g. image/png, which is returned as content instead of structured JSON.
+func mcpBinaryOutput(opt *Option) bool {
+	t := clientOutputType(opt)
+	if t == nil || t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(opt.ContentType)
+	return mediaType != "" && mediaType != JSON
+}
+
+// mcpRichOutput reports whether tool results are content blocks, they have no outputSchema
+// and no structuredContent.
+func mcpRichOutput(opt *Option) bool {
+	t := clientOutputType(opt)
+	if t == nil {
+		return false
+	}
+	return mcpBinaryOutput(opt) || t.Implements(mcpContentProviderType) || reflect.PointerTo(t).Implements(mcpContentProviderType)
+}
+
+// mcpBinaryContent decodes the base64 JSON of a []byte output.
+func mcpBinaryContent(output any) ([]byte, error) {
+	s, ok := output.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected binary output %T", output)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// mcpToolContent turns a rich output decoded from JSON into content blocks.
+func mcpToolContent(opt *Option, output any) ([]MCPContent, error) {
+	if mcpBinaryOutput(opt) {
+		data, err := mcpBinaryContent(output)
+		if err != nil {
+			return nil, err
+		}
+		mediaType, _, _ := mime.ParseMediaType(opt.ContentType)
+		switch {
+		case strings.HasPrefix(mediaType, "image/"):
+			return []MCPContent{MCPImage(data, opt.ContentType)}, nil
+		case strings.HasPrefix(mediaType, "audio/"):
+			return []MCPContent{MCPAudio(data, opt.ContentType)}, nil
+		}
+		return []MCPContent{MCPResource(mcpFunctionResourcePrefix+mcpFunctionName(opt), opt.ContentType, data)}, nil
+	}
+
+	// the output crossed the job pipeline as JSON, decode it back into the output type
+	buf, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(clientOutputType(opt))
+	if err := json.Unmarshal(buf, v.Interface()); err != nil {
+		return nil, err
+	}
+	provider, ok := v.Elem().Interface().(MCPContentProvider)
+	if !ok {
+		provider, ok = v.Interface().(MCPContentProvider)
+	}
+	if !ok {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(provider); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	return provider.MCPContent(), nil
+}
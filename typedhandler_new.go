@@ -10,6 +10,9 @@ import (
 )
 
 type Option struct {
+	// Group applies a path prefix, defaults and request handlers shared with other functions.
+	Group *RouteGroup
+
 	// HTTP
 	Path               string
 	Method             string
@@ -18,6 +21,7 @@ type Option struct {
 	CORS               bool
 	CORSCustomHeader   map[string]string
 	RequestHandler     func(r *Runtime, input any) (consumed bool, err error)
+	Middleware         []Middleware // wraps every invocation, see Server.Use
 	ResponseHandler    func(r *Runtime, output any) (consumed bool)
 	ResponseStatusCode int
 	ErrorHandler       func(r *Runtime, err error) (consumed bool)
@@ -25,11 +29,13 @@ type Option struct {
 	RedirectStatusCode int
 	NoWrapJSON         bool
 	HTMLTemplate       string
+	MaxBodySize        ByteSize // optional: limit for post tagged request bodies
 
 	// Session
 	Session SessionOption
 
-	// ACL
+	// Auth / ACL
+	Auth        string // optional: AuthLogin or AuthWritable, also documented as OpenAPI security
 	ACLResource string
 	ACLAction   string
 
@@ -42,18 +48,26 @@ type Option struct {
 	Next []Function
 
 	// AI (experimental)
-	SystemPrompt string
-	Tools        []Function
-	MCP          string // "tool", "resource", "prompt"
+	SystemPrompt   string
+	Tools          []Function
+	MCP            string              // "tool", "resource", "prompt"
+	MCPAnnotations *MCPToolAnnotations // optional: tool hints, defaults from Method and JobMode
+	MCPComplete    MCPCompleteFunc     // optional: completion/complete for prompt and resource template arguments
+
+	// API versioning
+	APIVersion string    // optional: "v1", mounted at /v1<Path> or selected by Accept-Version (routing.versioning)
+	Deprecated bool      // optional: sends "Deprecation: true", openapi deprecated
+	Sunset     time.Time // optional: sends the "Sunset" header
 
 	// Semantics
-	Package     string // optional: override auto package detection, used for route printing,
-	Name        string // required: job
-	Version     string // required: job
-	Summary     string // optional: openapi
-	Description string // optional: openapi, tools, mcp
-	Class       string // experimental
-	Extra       any    // optional
+	Package     string            // optional: override auto package detection, used for route printing,
+	Name        string            // required: job
+	Version     string            // required: job
+	Summary     string            // optional: openapi
+	Description string            // optional: openapi, tools, mcp
+	Examples    []FunctionExample // optional: openapi, mcp, route
+	Class       string            // experimental
+	Extra       any               // optional
 
 	// Reflection Hints
 	InputTypeHint  any
@@ -67,13 +81,20 @@ type Option struct {
 	// cache
 	parsedTemplate *template.Template
 	invoker        functionInvoker
+	caller         functionCaller
+	jobResult      functionJobResult
+	mcpTool        *MCPTool // remote tool re-exported by the MCP gateway
 
+	apiPath          string
 	inputType        reflect.Type
 	outputType       reflect.Type
 	errorType        reflect.Type
 	eiserror         bool
 	hasSelfDiscovery bool
 	inputReflectPlan *reflectPlan
+	streaming        bool
+	responseFields   []*responseField
+	websocket        bool
 
 	lastRun *time.Time
 	exts    *sync.Map
@@ -3,30 +3,62 @@ package allino
 import (
 	"fmt"
 	"mime/multipart"
+	"path"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
-
-	"github.com/wh-kuromai/jsonino"
 )
 
 type OpenAPI struct {
-	OpenAPI string                           `json:"openapi" `
-	Info    map[string]interface{}           `json:"info"`
-	Paths   map[string]map[string]*Operation `json:"paths"`
+	OpenAPI    string                           `json:"openapi" `
+	Info       map[string]interface{}           `json:"info"`
+	Tags       []*Tag                           `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]map[string]any  `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"` // "http", "apiKey"
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"` // "header", "query", "cookie"
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to scopes, all schemes in one requirement are needed together.
+type SecurityRequirement map[string][]string
+
+type Tag struct {
+	Name string `json:"name"`
 }
+
 type Operation struct {
-	Summary     string               `json:"summary,omitempty"`
-	Description string               `json:"description,omitempty"`
-	Parameters  []*Parameter         `json:"parameters,omitempty"`
-	RequestBody *RequestBody         `json:"requestBody,omitempty"`
-	Responses   map[string]*Response `json:"responses,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses,omitempty"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
 }
 
 type Parameter struct {
-	Name     string `json:"name"`
-	In       string `json:"in"` // "query", "path", etc.
-	Required bool   `json:"required"`
-	Schema   any    `json:"schema,omitempty"`
+	Name        string              `json:"name"`
+	In          string              `json:"in"` // "query", "path", etc.
+	Description string              `json:"description,omitempty"`
+	Required    bool                `json:"required"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Schema      any                 `json:"schema,omitempty"`
+	Examples    map[string]*Example `json:"examples,omitempty"`
 }
 
 type RequestBody struct {
@@ -56,43 +88,254 @@ type Example struct {
 	Value       any    `json:"value,omitempty"`
 }
 
+var operationIDRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// openapiBuilder keeps the state shared between operations of one document.
+type openapiBuilder struct {
+	openapi      *OpenAPI
+	schemas      *schemaRegistry
+	operationIDs map[string]bool
+	tags         map[string]bool
+	security     map[string]*SecurityScheme
+	versionParam string // header versioning: name of the version header
+}
+
 func (r *Server) GenerateOpenAPI() *OpenAPI {
+	return r.generateOpenAPI("")
+}
+
+// GenerateOpenAPIVersion generates the document of one API version: functions with that
+// Option.APIVersion and the functions without a version.
+func (r *Server) GenerateOpenAPIVersion(version string) *OpenAPI {
+	return r.generateOpenAPI(version)
+}
 
+func (r *Server) generateOpenAPI(version string) *OpenAPI {
+	infoVersion := r.Config.Version
+	if version != "" {
+		infoVersion = version
+	}
 	openapi := &OpenAPI{
 		OpenAPI: "3.1.0",
 		Info: map[string]interface{}{
 			"title":   r.Config.AppName,
-			"version": r.Config.Version,
+			"version": infoVersion,
 		},
 		Paths: make(map[string]map[string]*Operation),
 	}
 
+	b := &openapiBuilder{
+		openapi:      openapi,
+		schemas:      newSchemaRegistry(),
+		operationIDs: map[string]bool{},
+		tags:         map[string]bool{},
+		security:     securitySchemesFromLogin(&r.Config.Login),
+	}
+	if r.versionByHeader() {
+		b.versionParam = r.versionHeader()
+	}
+
 	// 1. FunctionCache から
 	for _, h := range r.FunctionCache {
 		opt := h.Options()
-		addOperationToOpenAPI(opt, openapi)
+		if version != "" && opt.APIVersion != "" && !sameVersion(opt.APIVersion, version) {
+			continue
+		}
+		b.addOperation(opt)
 	}
 
 	// 2. optionsCache から（通常の http.Handler も含める想定）
 	for _, opt := range r.optionsCache {
-		addOperationToOpenAPI(opt, openapi)
+		b.addOperation(opt)
+	}
+
+	for name := range b.tags {
+		openapi.Tags = append(openapi.Tags, &Tag{Name: name})
+	}
+	sort.Slice(openapi.Tags, func(i, j int) bool {
+		return openapi.Tags[i].Name < openapi.Tags[j].Name
+	})
+
+	if len(b.schemas.schemas) > 0 || len(b.security) > 0 {
+		openapi.Components = &Components{
+			Schemas:         b.schemas.schemas,
+			SecuritySchemes: b.security,
+		}
 	}
 	return openapi
 }
 
-func addOperationToOpenAPI(opt *Option, openapi *OpenAPI) {
-	method := strings.ToLower(opt.Method)
-	path := opt.Path
+// addOperation adds the operation of opt, and one more per SubMethod.
+func (b *openapiBuilder) addOperation(opt *Option) {
+	if opt.Method == "" {
+		opt.Method = "GET"
+	}
+	p, pathParams := templatePath(opt.RoutePath())
+
+	if _, ok := b.openapi.Paths[p]; !ok {
+		b.openapi.Paths[p] = make(map[string]*Operation)
+	}
+
+	tag := operationTag(opt)
+	if tag != "" {
+		b.tags[tag] = true
+	}
+
+	for i, m := range append([]string{opt.Method}, opt.SubMethod...) {
+		method := strings.ToLower(m)
+		if _, ok := b.openapi.Paths[p][method]; ok {
+			continue
+		}
+
+		op := generateOperationFromOptions(opt, b.schemas)
+		op.Parameters = addMissingPathParameters(op.Parameters, pathParams)
+		id := operationID(opt, m)
+		if i > 0 && opt.Name != "" {
+			id += "_" + method
+		}
+		op.OperationID = b.uniqueOperationID(id)
+		if tag != "" {
+			op.Tags = []string{tag}
+		}
+		op.Security = operationSecurity(opt, b.security)
+		if b.versionParam != "" && opt.APIVersion != "" {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:   b.versionParam,
+				In:     "header",
+				Schema: map[string]any{"type": "string", "enum": []any{opt.APIVersion}},
+			})
+		}
+		b.openapi.Paths[p][method] = op
+	}
+}
+
+func (b *openapiBuilder) uniqueOperationID(id string) string {
+	uid := id
+	for i := 2; b.operationIDs[uid]; i++ {
+		uid = fmt.Sprintf("%s_%d", id, i)
+	}
+	b.operationIDs[uid] = true
+	return uid
+}
+
+// operationID returns Option.Name, or "<package>_<method>_<path>" for unnamed functions.
+func operationID(opt *Option, method string) string {
+	if opt.Name != "" {
+		return strings.Trim(operationIDRe.ReplaceAllString(opt.Name, "_"), "_")
+	}
+	id := strings.ToLower(method) + "_" + opt.RoutePath()
+	if opt.Package != "" {
+		id = path.Base(opt.Package) + "_" + id
+	}
+	return strings.Trim(operationIDRe.ReplaceAllString(id, "_"), "_")
+}
 
-	if _, ok := openapi.Paths[path]; !ok {
-		openapi.Paths[path] = make(map[string]*Operation)
+// operationTag groups operations by Option.Class, the RouteGroup name, or the last element of the package path.
+func operationTag(opt *Option) string {
+	if opt.Class != "" {
+		return opt.Class
+	}
+	if opt.Group != nil && opt.Group.Name != "" {
+		return opt.Group.Name
+	}
+	if opt.Package != "" {
+		return path.Base(opt.Package)
 	}
-	openapi.Paths[path][method] = generateOperationFromOptions(opt)
+	return ""
 }
 
-func parseParametersAndFormData(t reflect.Type) (
+// addMissingPathParameters declares template parameters that no input field is bound to.
+func addMissingPathParameters(params []*Parameter, names []string) []*Parameter {
+	for _, name := range names {
+		found := false
+		for _, p := range params {
+			if p.In == "path" && p.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			params = append(params, &Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   map[string]any{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+// securitySchemesFromLogin describes the credentials accepted by Runtime.User().
+func securitySchemesFromLogin(c *LoginConfig) map[string]*SecurityScheme {
+	schemes := map[string]*SecurityScheme{}
+	if c.OAuth.AuthBearer {
+		schemes["bearerAuth"] = &SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  "Access token or API key (IssueAccessToken, IssueAPIKey)",
+		}
+	}
+	if c.OAuth.QueryKey != "" {
+		schemes["accessTokenQuery"] = &SecurityScheme{
+			Type: "apiKey",
+			In:   "query",
+			Name: c.OAuth.QueryKey,
+		}
+	}
+	if c.LoginCookie.Name != "" {
+		schemes["loginCookie"] = &SecurityScheme{
+			Type:        "apiKey",
+			In:          "cookie",
+			Name:        c.LoginCookie.Name,
+			Description: "Login cookie (IssueLoginCookie), read-only without csrfToken",
+		}
+	}
+	if c.CSRFToken.JWTAudience != "" {
+		schemes["csrfToken"] = &SecurityScheme{
+			Type:        "apiKey",
+			In:          "header",
+			Name:        "X-CSRF-Token",
+			Description: "CSRF token (IssueCSRFToken) sent with the login cookie",
+		}
+	}
+	if c.GuestCookie.Name != "" {
+		schemes["guestCookie"] = &SecurityScheme{
+			Type:        "apiKey",
+			In:          "cookie",
+			Name:        c.GuestCookie.Name,
+			Description: "Guest session cookie (IssueGuestCookie)",
+		}
+	}
+	return schemes
+}
+
+// operationSecurity returns the alternatives accepted for Option.Auth and ACLResource, nil for public operations.
+func operationSecurity(opt *Option, schemes map[string]*SecurityScheme) []SecurityRequirement {
+	if opt.Auth == "" && opt.ACLResource == "" {
+		return nil
+	}
+
+	var security []SecurityRequirement
+	for _, name := range []string{"bearerAuth", "accessTokenQuery"} {
+		if schemes[name] != nil {
+			security = append(security, SecurityRequirement{name: {}})
+		}
+	}
+	if schemes["loginCookie"] != nil {
+		if opt.Auth != AuthWritable {
+			security = append(security, SecurityRequirement{"loginCookie": {}})
+		} else if schemes["csrfToken"] != nil {
+			security = append(security, SecurityRequirement{"loginCookie": {}, "csrfToken": {}})
+		}
+	}
+	return security
+}
+
+func parseParametersAndFormData(t reflect.Type, sr *schemaRegistry) (
 	params []*Parameter,
-	formSchema *jsonino.ObjectScheme,
+	formSchema map[string]any,
 	usesMultipart bool,
 ) {
 	for i := 0; i < t.NumField(); i++ {
@@ -114,39 +357,67 @@ func parseParametersAndFormData(t reflect.Type) (
 			continue
 		}
 
-		tschema, _ := jsonino.SchemaFrom(field.Type)
+		tschema := annotateSchema(sr.schema(field.Type), field)
 
 		switch in {
 		case "path", "query":
+			if in == "path" {
+				name = pathParamName(name)
+			}
+			deprecated, _ := tschema["deprecated"].(bool)
 			params = append(params, &Parameter{
-				Name:     name,
-				In:       in,
-				Required: true, // path/query はとりあえず required 扱い
-				Schema:   tschema,
+				Name:        name,
+				In:          in,
+				Description: field.Tag.Get("description"),
+				Required:    in == "path" || isRequired(field),
+				Deprecated:  deprecated,
+				Schema:      tschema,
 			})
 		case "form":
 			if formSchema == nil {
-				formSchema = &jsonino.ObjectScheme{
-					TypeName:   "object",
-					Properties: make(map[string]*jsonino.Schema),
+				formSchema = map[string]any{
+					"type":       "object",
+					"properties": map[string]any{},
 				}
 			}
 
-			if field.Type == reflect.TypeOf((*multipart.FileHeader)(nil)) {
+			if field.Type == reflect.TypeOf((*multipart.FileHeader)(nil)) || isUploadType(field.Type) {
 				usesMultipart = true
-				formSchema.Properties[name] = &jsonino.Schema{
-					TypeName: "string",
-					Format:   "binary",
-				}
-			} else {
-				formSchema.Properties[name] = tschema
 			}
+			formSchema["properties"].(map[string]any)[name] = tschema
 		}
 	}
 	return
 }
 
-func generateOperationFromOptions(opt *Option) *Operation {
+// parsePostBody returns the request body media types of post:"xxx" tagged fields.
+func parsePostBody(t reflect.Type, sr *schemaRegistry) map[string]*MediaType {
+	var content map[string]*MediaType
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		format, ok := field.Tag.Lookup("post")
+		if !ok {
+			continue
+		}
+
+		var schema any
+		if format == "raw" {
+			schema = map[string]any{"type": "string", "format": "binary"}
+		} else {
+			schema = annotateSchema(sr.schema(field.Type), field)
+		}
+
+		for _, ct := range postBodyMediaTypes(format) {
+			if content == nil {
+				content = make(map[string]*MediaType)
+			}
+			content[ct] = &MediaType{Schema: schema}
+		}
+	}
+	return content
+}
+
+func generateOperationFromOptions(opt *Option, sr *schemaRegistry) *Operation {
 	if opt.Method == "" {
 		opt.Method = "GET"
 	}
@@ -158,15 +429,15 @@ func generateOperationFromOptions(opt *Option) *Operation {
 	}
 
 	inputType := opt.inputType
-	if inputType.Kind() == reflect.Ptr {
+	if inputType != nil && inputType.Kind() == reflect.Ptr {
 		inputType = inputType.Elem()
 	}
 
 	var params []*Parameter
-	var formSchema *jsonino.ObjectScheme
+	var formSchema map[string]any
 	var usesMultipart bool
-	if inputType.Kind() == reflect.Struct {
-		params, formSchema, usesMultipart = parseParametersAndFormData(inputType)
+	if inputType != nil && inputType.Kind() == reflect.Struct {
+		params, formSchema, usesMultipart = parseParametersAndFormData(inputType, sr)
 	}
 
 	var requestBody *RequestBody
@@ -192,21 +463,27 @@ func generateOperationFromOptions(opt *Option) *Operation {
 		}
 	}
 
-	//var err error
-	var node *jsonino.Schema
-	//var err error
-	if opt.outputType != nil {
-		node, _ = jsonino.SchemaFrom(opt.outputType)
+	if inputType != nil && inputType.Kind() == reflect.Struct {
+		if content := parsePostBody(inputType, sr); content != nil {
+			if requestBody == nil {
+				requestBody = &RequestBody{Content: map[string]*MediaType{}}
+			}
+			for ct, mt := range content {
+				requestBody.Content[ct] = mt
+			}
+		}
 	}
 
+	wrapped := openapiWrapped(opt)
 	mediaType := &MediaType{}
-	if node != nil {
-		mediaType.Schema = node
+	if opt.outputType != nil {
+		mediaType.Schema = openapiBodySchema(sr, opt.outputType, wrapped, "data")
 	}
 
 	op := &Operation{
 		Summary:     opt.Summary,
 		Description: opt.Description,
+		Deprecated:  opt.Deprecated || !opt.Sunset.IsZero(),
 		Parameters:  params,
 		RequestBody: requestBody,
 		Responses: map[string]*Response{
@@ -215,28 +492,40 @@ func generateOperationFromOptions(opt *Option) *Operation {
 				Content: map[string]*MediaType{
 					opt.ContentType: mediaType,
 				},
+				Headers: responseHeadersFromFields(opt.responseFields),
 			},
 		},
 	}
 
+	addOperationExamples(op, opt)
+
+	if opt.websocket {
+		// messages are described by GenerateAsyncAPI.
+		op.Responses = map[string]*Response{
+			"101": {Description: "Switching Protocols (WebSocket)"},
+		}
+		return op
+	}
+
 	//var err error
 	if opt.errorType != nil {
-		pv := opt.errorType
+		pv := unwrapAPIType(opt.errorType)
 		if pv.Kind() == reflect.Pointer {
 			pv = pv.Elem()
 		}
 
+		// errors without a JSON form are written as Error by errorJSON.
+		if pv.Kind() == reflect.Interface && opt.ContentType == JSON {
+			pv = tAPIError
+		}
 		if pv.Kind() == reflect.Struct {
-			errnode, err := jsonino.SchemaFrom(opt.errorType)
-			if err == nil {
-				op.Responses[fmt.Sprintf("%d", opt.ErrorStatusCode)] = &Response{
-					Description: "Error",
-					Content: map[string]*MediaType{
-						opt.ContentType: {
-							Schema: errnode,
-						},
+			op.Responses[fmt.Sprintf("%d", opt.ErrorStatusCode)] = &Response{
+				Description: "Error",
+				Content: map[string]*MediaType{
+					opt.ContentType: {
+						Schema: openapiBodySchema(sr, pv, wrapped, "error"),
 					},
-				}
+				},
 			}
 		}
 
@@ -244,3 +533,21 @@ func generateOperationFromOptions(opt *Option) *Operation {
 
 	return op
 }
+
+// openapiWrapped reports whether the JSON response handler writes {"data": ...} and
+// {"error": ...}. Type hints describe the body as is.
+func openapiWrapped(opt *Option) bool {
+	return opt.ContentType == JSON && !opt.NoWrapJSON && opt.OutputTypeHint == nil
+}
+
+// openapiBodySchema is the schema of t, in the envelope under key when wrapped.
+func openapiBodySchema(sr *schemaRegistry, t reflect.Type, wrapped bool, key string) map[string]any {
+	if !wrapped {
+		return sr.schema(t)
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{key: sr.schema(unwrapAPIType(t))},
+		"required":   []string{key},
+	}
+}
@@ -0,0 +1,275 @@
+package allino
+
+import (
+	"crypto/sha512"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+)
+
+const defaultOpenAPIDocsPath = "/openapi"
+
+// OpenAPIDocsConfig enables the live OpenAPI document and a documentation UI:
+// GET <path> (UI), <path>.json and <path>.yaml, ?version=v1 selects one API version.
+// The routes answer in debug mode, or when ACLResource is set and the Casbin ACL allows the user.
+type OpenAPIDocsConfig struct {
+	Enable      bool   `json:"enable"`
+	Path        string `json:"path"`        // default "/openapi"
+	UI          string `json:"ui"`          // "swagger" (default) or "redoc"
+	ACLResource string `json:"aclResource"` // optional: required outside debug mode
+	ACLAction   string `json:"aclAction"`   // default "read"
+
+	// AssetsURL is the base URL of the UI files, e.g. "/static/swagger-ui" to serve them from
+	// another location. Defaults to the files embedded and served under <path>/assets/.
+	AssetsURL string `json:"assetsURL"`
+	// Integrity is the SRI hash of the UI files by file name, e.g. "swagger-ui-bundle.js".
+	// The hashes of the embedded files are computed.
+	Integrity map[string]string `json:"integrity"`
+}
+
+//go:generate sh openapi_docs/fetch.sh
+
+// openapiDocsFiles are the UI files served under <path>/assets/. The pinned swagger-ui-dist
+// and redoc files are downloaded by go generate, the jsDelivr URLs are used while they are missing.
+//
+//go:embed openapi_docs
+var openapiDocsFiles embed.FS
+
+const (
+	swaggerUIAssetsURL = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14"
+	redocAssetsURL     = "https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles"
+)
+
+// openapiDocsAssetTypes are the files of openapiDocsFiles that are served.
+var openapiDocsAssetTypes = map[string]string{
+	"swagger-ui.css":       "text/css; charset=utf-8",
+	"swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+	"swagger-init.js":      "text/javascript; charset=utf-8",
+	"redoc.standalone.js":  "text/javascript; charset=utf-8",
+}
+
+var openapiDocsIntegrity sync.Map
+
+// openapiDocsAsset is a file of the documentation UI.
+type openapiDocsAsset struct {
+	URL       string
+	Integrity string
+}
+
+// openapiDocsEmbedded returns the embedded file and its SRI hash, ok is false when it is missing.
+func openapiDocsEmbedded(file string) (buf []byte, integrity string, ok bool) {
+	if _, served := openapiDocsAssetTypes[file]; !served {
+		return nil, "", false
+	}
+	buf, err := openapiDocsFiles.ReadFile("openapi_docs/" + file)
+	if err != nil {
+		return nil, "", false
+	}
+	if v, ok := openapiDocsIntegrity.Load(file); ok {
+		return buf, v.(string), true
+	}
+	sum := sha512.Sum384(buf)
+	integrity = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	openapiDocsIntegrity.Store(file, integrity)
+	return buf, integrity, true
+}
+
+// openapiDocsAssets returns the URLs of files: from AssetsURL when set, else the embedded
+// files under <path>/assets/, else cdnURL.
+func openapiDocsAssets(p, cdnURL string, files ...string) map[string]openapiDocsAsset {
+	config := openapiDocsConfig()
+	base := strings.TrimRight(config.AssetsURL, "/")
+	assets := map[string]openapiDocsAsset{}
+	for _, file := range files {
+		asset := openapiDocsAsset{Integrity: config.Integrity[file]}
+		if base != "" {
+			asset.URL = base + "/" + file
+		} else if _, integrity, ok := openapiDocsEmbedded(file); ok {
+			asset.URL = p + "/assets/" + file
+			if asset.Integrity == "" {
+				asset.Integrity = integrity
+			}
+		} else {
+			asset.URL = cdnURL + "/" + file
+		}
+		assets[file] = asset
+	}
+	return assets
+}
+
+var ErrDocsNotFound = NewCodeError(404, "not_found", "not found")
+
+var openapiDocsRegisteredServers sync.Map
+
+var OpenAPIDocsExtension = NewExtension[OpenAPIDocsConfig, any](
+	"openapi",
+	&ExtOption{
+		OnInit: func(s *Server, virtual *Runtime) error {
+			if openapiDocsConfig().Enable {
+				registerOpenAPIDocsHandlers(s)
+			}
+			return nil
+		},
+		OnShutdown: func(s *Server, virtual *Runtime) error {
+			openapiDocsRegisteredServers.Delete(s)
+			return nil
+		},
+	},
+)
+
+func openapiDocsConfig() *OpenAPIDocsConfig {
+	for _, ext := range extensionList {
+		docsExt, ok := ext.(*Extension[OpenAPIDocsConfig, any])
+		if ok && docsExt.Option.Name == "openapi" {
+			return docsExt.Config
+		}
+	}
+	return &OpenAPIDocsConfig{}
+}
+
+func openapiDocsPath() string {
+	p := strings.TrimRight(strings.TrimSpace(openapiDocsConfig().Path), "/")
+	if p == "" {
+		return defaultOpenAPIDocsPath
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return p
+}
+
+func registerOpenAPIDocsHandlers(s *Server) {
+	if _, loaded := openapiDocsRegisteredServers.LoadOrStore(s, true); loaded {
+		return
+	}
+	p := openapiDocsPath()
+
+	// the document is generated per request, so functions registered later are included.
+	s.HandleRequestFunc(http.MethodGet, p+".json", func(r *Runtime) {
+		if !r.openapiDocsAllowed() {
+			return
+		}
+		buf, err := json.MarshalIndent(openapiDocsDocument(s, r), "", "  ")
+		if err != nil {
+			r.errorJSON(500, false, true, ErrServerError.With(err))
+			return
+		}
+		r.fiber.Set("Content-Type", JSON)
+		_ = r.fiber.Send(buf)
+	})
+
+	s.HandleRequestFunc(http.MethodGet, p+".yaml", func(r *Runtime) {
+		if !r.openapiDocsAllowed() {
+			return
+		}
+		buf, err := yaml.Marshal(openapiDocsDocument(s, r))
+		if err != nil {
+			r.errorJSON(500, false, true, ErrServerError.With(err))
+			return
+		}
+		r.fiber.Set("Content-Type", YAML)
+		_ = r.fiber.Send(buf)
+	})
+
+	s.HandleRequestFunc(http.MethodGet, p, func(r *Runtime) {
+		if !r.openapiDocsAllowed() {
+			return
+		}
+		tmpl := swaggerUITemplate
+		assets := openapiDocsAssets(p, swaggerUIAssetsURL, "swagger-ui.css", "swagger-ui-bundle.js")
+		if strings.EqualFold(openapiDocsConfig().UI, "redoc") {
+			tmpl = redocTemplate
+			assets = openapiDocsAssets(p, redocAssetsURL, "redoc.standalone.js")
+		}
+		// the start script is not part of the UI packages, it is always served from the assets.
+		_, integrity, _ := openapiDocsEmbedded("swagger-init.js")
+		assets["swagger-init.js"] = openapiDocsAsset{URL: p + "/assets/swagger-init.js", Integrity: integrity}
+
+		r.fiber.Set("Content-Type", HTML+"; charset=utf-8")
+		_ = tmpl.Execute(r.fiber, map[string]any{
+			"Title":   s.Config.AppName,
+			"SpecURL": p + ".json",
+			"Assets":  assets,
+		})
+	})
+
+	s.HandleRequestFunc(http.MethodGet, p+"/assets/:file", func(r *Runtime) {
+		if !r.openapiDocsAllowed() {
+			return
+		}
+		file := r.fiber.Params("file")
+		buf, _, ok := openapiDocsEmbedded(file)
+		if !ok {
+			r.errorJSON(404, false, true, ErrDocsNotFound)
+			return
+		}
+		r.fiber.Set("Content-Type", openapiDocsAssetTypes[file])
+		r.fiber.Set("Cache-Control", "public, max-age=86400")
+		_ = r.fiber.Send(buf)
+	})
+}
+
+// openapiDocsDocument returns the document of the ?version= API version, or of all functions.
+func openapiDocsDocument(s *Server, r *Runtime) *OpenAPI {
+	if v := r.fiber.Query("version"); v != "" {
+		return s.GenerateOpenAPIVersion(v)
+	}
+	return s.GenerateOpenAPI()
+}
+
+// openapiDocsAllowed writes the error response and returns false when the docs are not available.
+func (r *Runtime) openapiDocsAllowed() bool {
+	if r.config.Debug {
+		return true
+	}
+
+	config := openapiDocsConfig()
+	if config.ACLResource == "" {
+		r.errorJSON(404, false, true, ErrDocsNotFound)
+		return false
+	}
+
+	action := config.ACLAction
+	if action == "" {
+		action = "read"
+	}
+	if err := r.enforceACL(&Option{ACLResource: config.ACLResource, ACLAction: action}, nil); err != nil {
+		r.errorJSON(403, false, true, err)
+		return false
+	}
+	return true
+}
+
+var swaggerUITemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}} - API</title>
+  {{with index .Assets "swagger-ui.css"}}<link rel="stylesheet" href="{{.URL}}"{{if .Integrity}} integrity="{{.Integrity}}" crossorigin="anonymous"{{end}}>{{end}}
+</head>
+<body>
+  <div id="swagger-ui" data-url="{{.SpecURL}}"></div>
+  {{with index .Assets "swagger-ui-bundle.js"}}<script src="{{.URL}}"{{if .Integrity}} integrity="{{.Integrity}}" crossorigin="anonymous"{{end}}></script>{{end}}
+  {{with index .Assets "swagger-init.js"}}<script src="{{.URL}}"{{if .Integrity}} integrity="{{.Integrity}}" crossorigin="anonymous"{{end}}></script>{{end}}
+</body>
+</html>
+`))
+
+var redocTemplate = template.Must(template.New("redoc").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}} - API</title>
+</head>
+<body>
+  <redoc spec-url="{{.SpecURL}}"></redoc>
+  {{with index .Assets "redoc.standalone.js"}}<script src="{{.URL}}"{{if .Integrity}} integrity="{{.Integrity}}" crossorigin="anonymous"{{end}}></script>{{end}}
+</body>
+</html>
+`))
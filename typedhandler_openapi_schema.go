@@ -0,0 +1,168 @@
+package allino
+
+import (
+	"encoding"
+	"mime/multipart"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	tTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	tFileHeader    = reflect.TypeOf((*multipart.FileHeader)(nil)).Elem()
+
+	componentNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// schemaRegistry builds OpenAPI schemas from Go types.
+// Named struct types are emitted once under components/schemas and referenced with $ref.
+type schemaRegistry struct {
+	schemas map[string]map[string]any
+	names   map[reflect.Type]string
+	inline  map[reflect.Type]bool
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]map[string]any{},
+		names:   map[reflect.Type]string{},
+		inline:  map[reflect.Type]bool{},
+	}
+}
+
+func (sr *schemaRegistry) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == tTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == tFileHeader || t == tUploadPtr.Elem() || t == tIOReader:
+		return map[string]any{"type": "string", "format": "binary"}
+	case t.Implements(tTextMarshaler) || reflect.PointerTo(t).Implements(tTextMarshaler):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": sr.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": sr.schema(t.Elem())}
+	case reflect.Struct:
+		return sr.structRef(t)
+	}
+	return map[string]any{}
+}
+
+func (sr *schemaRegistry) structRef(t reflect.Type) map[string]any {
+	// generic wrappers (APIResponse[T] etc.) and anonymous structs stay inline.
+	if t.Name() == "" || strings.Contains(t.Name(), "[") {
+		if sr.inline[t] {
+			return map[string]any{"type": "object"}
+		}
+		sr.inline[t] = true
+		defer delete(sr.inline, t)
+		return sr.structSchema(t, nil)
+	}
+
+	name, ok := sr.names[t]
+	if !ok {
+		name = sr.componentName(t)
+		sr.names[t] = name
+		sr.schemas[name] = map[string]any{"type": "object"} // placeholder for recursive types
+		sr.schemas[name] = sr.structSchema(t, nil)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// componentName returns "Type", or "pkg.Type" when another package already uses the name.
+func (sr *schemaRegistry) componentName(t reflect.Type) string {
+	name := componentNameRe.ReplaceAllString(t.Name(), "_")
+	if _, used := sr.schemas[name]; !used {
+		return name
+	}
+	name = componentNameRe.ReplaceAllString(path.Base(t.PkgPath())+"."+t.Name(), "_")
+	base := name
+	for i := 2; ; i++ {
+		if _, used := sr.schemas[name]; !used {
+			return name
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+func (sr *schemaRegistry) structSchema(t reflect.Type, skip func(sf reflect.StructField) bool) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if skip != nil && skip(sf) {
+			continue
+		}
+		if slices.Contains(strings.Split(sf.Tag.Get("link"), ","), "passive") {
+			continue
+		}
+
+		jsonTag := sf.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if (sf.Anonymous && name == "" && ft.Kind() == reflect.Struct) || strings.Contains(opts, "inline") {
+			embedded := sr.structSchema(ft, skip)
+			if p, ok := embedded["properties"].(map[string]any); ok {
+				for k, v := range p {
+					props[k] = v
+				}
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		props[name] = annotateSchema(sr.schema(sf.Type), sf)
+
+		if sf.Tag.Get("required") == "true" || isRequired(sf) {
+			required = append(required, name)
+		}
+	}
+
+	out := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
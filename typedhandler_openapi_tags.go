@@ -0,0 +1,351 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FunctionExample is a sample call of a function, shown in OpenAPI, MCP tool schemas and the route output.
+type FunctionExample struct {
+	Name    string // optional: defaults to "example1", "example2" ...
+	Summary string
+	Input   any // optional: a value of the input type
+	Output  any // optional: a value of the output type, wrapped in {"data":...} like the response
+}
+
+// validateFormats maps validator rules to JSON Schema formats.
+var validateFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+// validatePatterns maps validator rules to JSON Schema patterns.
+var validatePatterns = map[string]string{
+	"alpha":    "^[a-zA-Z]+$",
+	"alphanum": "^[a-zA-Z0-9]+$",
+	"numeric":  "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+}
+
+// annotateSchema adds the description, example, enum, deprecated and format tags
+// and the validate rules of sf to schema, and returns schema.
+func annotateSchema(schema map[string]any, sf reflect.StructField) map[string]any {
+	if schema == nil {
+		return schema
+	}
+	t := sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for _, rule := range strings.Split(sf.Tag.Get("validate"), ",") {
+		if rule == "dive" {
+			// following rules apply to the elements
+			break
+		}
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "gte":
+			setSchemaBound(schema, t, param, "minLength", "minItems", "minimum")
+		case "max", "lte":
+			setSchemaBound(schema, t, param, "maxLength", "maxItems", "maximum")
+		case "gt":
+			setSchemaBound(schema, t, param, "", "", "exclusiveMinimum")
+		case "lt":
+			setSchemaBound(schema, t, param, "", "", "exclusiveMaximum")
+		case "len":
+			setSchemaBound(schema, t, param, "minLength", "minItems", "")
+			setSchemaBound(schema, t, param, "maxLength", "maxItems", "")
+		case "oneof":
+			var enum []any
+			for _, v := range strings.Fields(param) {
+				enum = append(enum, tagValue(t, strings.Trim(v, "'")))
+			}
+			schema["enum"] = enum
+		default:
+			if f, ok := validateFormats[name]; ok {
+				schema["format"] = f
+			} else if p, ok := validatePatterns[name]; ok {
+				schema["pattern"] = p
+			}
+		}
+	}
+
+	if v := sf.Tag.Get("description"); v != "" {
+		schema["description"] = v
+	}
+	if v := sf.Tag.Get("format"); v != "" {
+		schema["format"] = v
+	}
+	if v, ok := sf.Tag.Lookup("example"); ok {
+		schema["examples"] = []any{tagValue(t, v)}
+	}
+	if v := sf.Tag.Get("enum"); v != "" {
+		var enum []any
+		for _, e := range strings.Split(v, ",") {
+			enum = append(enum, tagValue(t, strings.TrimSpace(e)))
+		}
+		schema["enum"] = enum
+	}
+	if v, _ := strconv.ParseBool(sf.Tag.Get("deprecated")); v {
+		schema["deprecated"] = true
+	}
+	return schema
+}
+
+func setSchemaBound(schema map[string]any, t reflect.Type, param, strKey, itemsKey, numKey string) {
+	key := ""
+	switch t.Kind() {
+	case reflect.String:
+		key = strKey
+	case reflect.Slice, reflect.Array, reflect.Map:
+		key = itemsKey
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		key = numKey
+	}
+	if key == "" {
+		return
+	}
+	if t.Kind() == reflect.Map {
+		key = strings.Replace(key, "Items", "Properties", 1)
+	}
+	if n, err := strconv.ParseFloat(param, 64); err == nil {
+		schema[key] = n
+	}
+}
+
+// tagValue converts a tag value to the JSON value of type t.
+func tagValue(t reflect.Type, s string) any {
+	if t.Kind() == reflect.String || t.Implements(tTextMarshaler) || reflect.PointerTo(t).Implements(tTextMarshaler) {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
+
+// annotateSchemaMap adds the field tags of t to a schema generated by jsonino.
+func annotateSchemaMap(schema map[string]any, t reflect.Type) {
+	if schema == nil || t == nil {
+		return
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if t.Kind() != reflect.Ptr {
+			if items, ok := schema["items"].(map[string]any); ok {
+				schema = items
+			} else {
+				return
+			}
+		}
+		t = t.Elem()
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok || t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if slices.Contains(strings.Split(sf.Tag.Get("link"), ","), "passive") {
+			continue
+		}
+		jsonTag := sf.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if (sf.Anonymous && name == "" && ft.Kind() == reflect.Struct) || strings.Contains(opts, "inline") {
+			annotateSchemaMap(schema, ft)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if prop, ok := props[name].(map[string]any); ok {
+			annotateSchema(prop, sf)
+			annotateSchemaMap(prop, sf.Type)
+		}
+	}
+}
+
+// exampleName returns the name of the i-th example.
+func exampleName(ex FunctionExample, i int) string {
+	if ex.Name != "" {
+		return ex.Name
+	}
+	return fmt.Sprintf("example%d", i+1)
+}
+
+// exampleValue returns v as it is encoded in JSON.
+func exampleValue(v any) any {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(buf, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// exampleOutput returns the example output as the response body.
+func exampleOutput(opt *Option, out any) any {
+	if opt.ContentType == JSON && !opt.NoWrapJSON && opt.OutputTypeHint == nil {
+		return map[string]any{"data": exampleValue(out)}
+	}
+	return exampleValue(out)
+}
+
+// exampleFields calls fn with the tag kind ("path", "query", "form" or "post"), the name and the
+// value of the non-zero bound fields of an example input.
+func exampleFields(input any, fn func(in, name string, value any)) {
+	v := reflect.ValueOf(input)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+		if field.PkgPath != "" || fv.IsZero() {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("post"); ok {
+			fn("post", "", exampleValue(fv.Interface()))
+			continue
+		}
+		for _, in := range []string{"path", "query", "form"} {
+			if name := field.Tag.Get(in); name != "" {
+				if in == "path" {
+					name = pathParamName(name)
+				}
+				fn(in, name, exampleValue(fv.Interface()))
+				break
+			}
+		}
+	}
+}
+
+// addOperationExamples adds Option.Examples to the parameters, request body and response of op.
+func addOperationExamples(op *Operation, opt *Option) {
+	for i, ex := range opt.Examples {
+		name := exampleName(ex, i)
+
+		var form map[string]any
+		exampleFields(ex.Input, func(in, pname string, value any) {
+			switch in {
+			case "path", "query":
+				for _, p := range op.Parameters {
+					if p.In == in && p.Name == pname {
+						if p.Examples == nil {
+							p.Examples = map[string]*Example{}
+						}
+						p.Examples[name] = &Example{Summary: ex.Summary, Value: value}
+					}
+				}
+			case "form":
+				if form == nil {
+					form = map[string]any{}
+				}
+				form[pname] = value
+			case "post":
+				if op.RequestBody == nil {
+					return
+				}
+				for ct, mt := range op.RequestBody.Content {
+					if !isFormMediaType(ct) {
+						mt.addExample(name, ex.Summary, value)
+					}
+				}
+			}
+		})
+		if form != nil && op.RequestBody != nil {
+			for ct, mt := range op.RequestBody.Content {
+				if isFormMediaType(ct) {
+					mt.addExample(name, ex.Summary, form)
+				}
+			}
+		}
+
+		if ex.Output != nil {
+			if res := op.Responses[strconv.Itoa(opt.ResponseStatusCode)]; res != nil {
+				for _, mt := range res.Content {
+					mt.addExample(name, ex.Summary, exampleOutput(opt, ex.Output))
+				}
+			}
+		}
+	}
+}
+
+func isFormMediaType(ct string) bool {
+	return ct == "multipart/form-data" || ct == "application/x-www-form-urlencoded"
+}
+
+func (mt *MediaType) addExample(name, summary string, value any) {
+	if mt.Examples == nil {
+		mt.Examples = map[string]*Example{}
+	}
+	mt.Examples[name] = &Example{Summary: summary, Value: value}
+}
+
+// mcpSchemaExamples returns the example inputs or outputs as JSON Schema "examples".
+func mcpSchemaExamples(opt *Option, output bool) []any {
+	var out []any
+	for _, ex := range opt.Examples {
+		switch {
+		case output && ex.Output != nil:
+			out = append(out, exampleOutput(opt, ex.Output))
+		case !output && ex.Input != nil:
+			out = append(out, exampleValue(ex.Input))
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package allino
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// RemoteServer is another allino server called by RemoteFunction.
+type RemoteServer struct {
+	BaseURL string
+	Token   string       // optional: sent as "Authorization: Bearer"
+	Header  http.Header  // optional: added to every request
+	Client  *http.Client // optional: defaults to Runtime.HttpClient()
+}
+
+// RemoteFunction is a Function whose handler calls a function on a RemoteServer.
+// The input fields are sent with the same path/query/form/post/cookie/header tags
+// the remote handler binds them from.
+type RemoteFunction[T, U any] struct {
+	*GenericFunction[T, U, error]
+	Remote *RemoteServer
+	Path   string
+}
+
+// NewRemoteFunction describes a function of the remote server, option.Path and option.Method are the remote route.
+// The function is not routed on this server, use Call or list it in Option.Tools / Option.Next.
+func NewRemoteFunction[T, U any](remote *RemoteServer, option Option) *RemoteFunction[T, U] {
+	rf := &RemoteFunction[T, U]{
+		Remote: remote,
+		Path:   option.Path,
+	}
+	if option.ContentType == "" {
+		option.ContentType = JSON
+	}
+	method := strings.ToUpper(option.Method)
+	if method == "" {
+		method = "GET"
+	}
+	path, _ := templatePath(option.Path)
+	wrapped := option.ContentType == JSON && !option.NoWrapJSON
+
+	option.Path = ""
+	rf.GenericFunction = NewFunction(option, func(r *Runtime, input T) (U, error) {
+		var output U
+		req := newClientRequest(method, path)
+		bindRemoteInput(req, rf.options.inputReflectPlan, reflect.ValueOf(input))
+
+		httpreq, err := req.build(r.Context(), remote.BaseURL, remote.Token)
+		if err != nil {
+			return output, err
+		}
+		for k, v := range remote.Header {
+			httpreq.Header[k] = v
+		}
+
+		client := remote.Client
+		if client == nil {
+			client = r.HttpClient()
+		}
+		err = clientDo(client, httpreq, wrapped, &output, func(status int, code, msg string) error {
+			return NewCodeError(status, code, msg)
+		})
+		return output, err
+	})
+	return rf
+}
+
+var remoteTagOrder = []tagKind{tagPath, tagQuery, tagForm, tagPost, tagJWT, tagCookie, tagHeader}
+
+// bindRemoteInput mirrors getByStructField: the first tag found decides where a field goes.
+func bindRemoteInput(q *clientRequest, plan *reflectPlan, v reflect.Value) {
+	if plan == nil {
+		return
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i, fp := range plan.fields {
+		if fp == nil {
+			continue
+		}
+		fv := v.Field(i)
+		if fp.child != nil {
+			bindRemoteInput(q, fp.child, fv)
+			continue
+		}
+
+		for _, k := range remoteTagOrder {
+			if !fp.tagoks[k] {
+				continue
+			}
+			name := fp.tags[k]
+			switch k {
+			case tagPath:
+				q.setPath(pathParamName(name), fv.Interface())
+			case tagQuery:
+				q.addQuery(name, fv.Interface())
+			case tagForm:
+				q.addForm(name, fv.Interface())
+			case tagPost:
+				q.setBody(name, fv.Interface())
+			case tagCookie:
+				q.setCookie(name, fv.Interface())
+			case tagHeader:
+				q.setHeader(name, fv.Interface())
+			}
+			// jwt fields come from the token
+			break
+		}
+	}
+}
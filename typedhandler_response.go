@@ -0,0 +1,209 @@
+package allino
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wh-kuromai/jsonino"
+)
+
+var tFiberCookiePtr = reflect.TypeOf((*fiber.Cookie)(nil))
+
+// responseField is an output struct field tagged with header:"X-Name", cookie:"name" or status:"".
+// It must have json:"-" to keep it out of the response body.
+type responseField struct {
+	index []int
+	kind  string // "header", "cookie", "status"
+	name  string
+	typ   reflect.Type
+}
+
+func buildResponseFields(t reflect.Type) ([]*responseField, error) {
+	return collectResponseFields(t, false)
+}
+
+// collectResponseFields walks t and its embedded structs, hidden is set below a json:"-" field.
+func collectResponseFields(t reflect.Type, hidden bool) ([]*responseField, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var fields []*responseField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			children, err := collectResponseFields(sf.Type, hidden || sf.Tag.Get("json") == "-")
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				child.index = append([]int{i}, child.index...)
+				fields = append(fields, child)
+			}
+			continue
+		}
+
+		for _, kind := range []string{"header", "cookie", "status"} {
+			name, ok := sf.Tag.Lookup(kind)
+			if !ok {
+				continue
+			}
+			if !hidden && sf.Tag.Get("json") != "-" {
+				return nil, fmt.Errorf("%s.%s is a %s field, add json:\"-\" to keep it out of the body", t.Name(), sf.Name, kind)
+			}
+			if name == "" && kind != "status" {
+				name = sf.Name
+			}
+			fields = append(fields, &responseField{
+				index: []int{i},
+				kind:  kind,
+				name:  name,
+				typ:   sf.Type,
+			})
+			break
+		}
+	}
+	return fields, nil
+}
+
+// applyResponseFields sets headers and cookies from the output struct and returns the response status code.
+func (r *Runtime) applyResponseFields(options *Option, output any) int {
+	status := options.ResponseStatusCode
+	if len(options.responseFields) == 0 || isReallyNil(output) {
+		return status
+	}
+
+	v := reflect.ValueOf(output)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return status
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return status
+	}
+
+	for _, f := range options.responseFields {
+		fv := v.FieldByIndex(f.index)
+		for fv.Kind() == reflect.Ptr && fv.Type() != tFiberCookiePtr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			continue
+		}
+
+		switch f.kind {
+		case "status":
+			if fv.CanInt() && fv.Int() != 0 {
+				status = int(fv.Int())
+			}
+		case "header":
+			if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+				for i := 0; i < fv.Len(); i++ {
+					r.fiber.Append(f.name, responseValueString(fv.Index(i)))
+				}
+				continue
+			}
+			if s := responseValueString(fv); s != "" {
+				r.fiber.Set(f.name, s)
+			}
+		case "cookie":
+			if c, ok := fv.Interface().(*fiber.Cookie); ok {
+				if c.Name == "" {
+					c.Name = f.name
+				}
+				r.fiber.Cookie(c)
+				continue
+			}
+			if c, ok := fv.Interface().(fiber.Cookie); ok {
+				if fv.IsZero() {
+					continue
+				}
+				if c.Name == "" {
+					c.Name = f.name
+				}
+				r.fiber.Cookie(&c)
+				continue
+			}
+			if s := responseValueString(fv); s != "" {
+				r.fiber.Cookie(&fiber.Cookie{
+					Name:     f.name,
+					Value:    s,
+					Path:     "/",
+					HTTPOnly: true,
+				})
+			}
+		}
+	}
+	return status
+}
+
+func responseValueString(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(http.TimeFormat)
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if v.IsZero() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// responseHeadersFromFields documents header/cookie tagged output fields for OpenAPI.
+func responseHeadersFromFields(fields []*responseField) map[string]*Header {
+	var headers map[string]*Header
+	var cookies []string
+	for _, f := range fields {
+		switch f.kind {
+		case "header":
+			if headers == nil {
+				headers = make(map[string]*Header)
+			}
+			t := f.typ
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+				t = t.Elem()
+			}
+			schema, _ := jsonino.SchemaFrom(t)
+			headers[f.name] = &Header{Schema: schema}
+		case "cookie":
+			cookies = append(cookies, f.name)
+		}
+	}
+
+	if len(cookies) > 0 {
+		if headers == nil {
+			headers = make(map[string]*Header)
+		}
+		headers["Set-Cookie"] = &Header{
+			Description: "Sets cookie: " + strings.Join(cookies, ", "),
+			Schema:      &jsonino.Schema{TypeName: "string"},
+		}
+	}
+	return headers
+}
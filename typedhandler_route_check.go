@@ -0,0 +1,95 @@
+package allino
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteConflict is a pair of routes that can serve the same request.
+//
+// "duplicate": same method and path, the later registration is never reached.
+// "ambiguous": e.g. /users/:id and /users/:name, the priority order of comparePaths
+// cannot tell them apart, so the registration order decides.
+type RouteConflict struct {
+	Kind   string
+	Method string
+	Path   string
+	Other  string
+}
+
+func (c *RouteConflict) String() string {
+	if c.Kind == "duplicate" {
+		return fmt.Sprintf("duplicate route: %s %s", c.Method, c.Path)
+	}
+	return fmt.Sprintf("ambiguous routes: %s %s and %s", c.Method, c.Path, c.Other)
+}
+
+// CheckRoutes reports duplicate or ambiguous routes of the registered functions.
+func (s *Server) CheckRoutes() []*RouteConflict {
+	return checkRouteConflicts(s.RegisteredFunctions())
+}
+
+type methodRoute struct {
+	method  string
+	path    string
+	version string // header versioning: routes of other versions do not conflict
+}
+
+func checkRouteConflicts(opts []*Option) []*RouteConflict {
+	var routes []methodRoute
+	for _, opt := range opts {
+		for _, m := range append([]string{opt.Method}, opt.SubMethod...) {
+			if m == "" {
+				m = "GET"
+			}
+			version := ""
+			if opt.APIVersion != "" && opt.RoutePath() == opt.Path {
+				version = strings.TrimPrefix(strings.ToLower(opt.APIVersion), "v")
+			}
+			routes = append(routes, methodRoute{strings.ToUpper(m), opt.RoutePath(), version})
+		}
+	}
+
+	var conflicts []*RouteConflict
+	reported := map[methodRoute]bool{}
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if a.method != b.method || (a.version != "" && b.version != "" && a.version != b.version) {
+				continue
+			}
+
+			if a.path == b.path {
+				if !reported[a] {
+					reported[a] = true
+					conflicts = append(conflicts, &RouteConflict{Kind: "duplicate", Method: a.method, Path: a.path})
+				}
+				continue
+			}
+
+			if routesOverlap(a.path, b.path) {
+				conflicts = append(conflicts, &RouteConflict{Kind: "ambiguous", Method: a.method, Path: a.path, Other: b.path})
+			}
+		}
+	}
+	return conflicts
+}
+
+// routesOverlap reports whether both paths have the same priority at every segment
+// and can match the same request path.
+func routesOverlap(p1, p2 string) bool {
+	if comparePaths(p1, p2) || comparePaths(p2, p1) {
+		return false
+	}
+
+	s1 := strings.Split(strings.Trim(p1, "/"), "/")
+	s2 := strings.Split(strings.Trim(p2, "/"), "/")
+	if len(s1) != len(s2) {
+		return false
+	}
+	for k := range s1 {
+		if getPriority(s1[k]) == 0 && s1[k] != s2[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"mime/multipart"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/wh-kuromai/jsonino"
@@ -16,7 +18,19 @@ func generateRouteFromOptions(opt *Option) (string, string) {
 		inputType = inputType.Elem()
 	}
 
-	path := opt.Method + " " + opt.Path
+	method := opt.Method
+	if opt.websocket {
+		method = "WS"
+	}
+
+	path := method + " " + opt.RoutePath()
+	if opt.APIVersion != "" && opt.RoutePath() == opt.Path {
+		// header versioning
+		path += " [" + opt.APIVersion + "]"
+	}
+	if opt.Deprecated || !opt.Sunset.IsZero() {
+		path += " (deprecated)"
+	}
 	body := ""
 
 	if inputType.Kind() == reflect.Struct {
@@ -36,7 +50,7 @@ func generateRouteFromOptions(opt *Option) (string, string) {
 		t := opt.outputType
 		if t == reflect.TypeOf((*[]byte)(nil)).Elem() {
 			body += "  Response:\n    Binary (" + opt.ContentType + ")"
-			return path, body
+			return path, strings.TrimPrefix(body+routeExamples(opt), "\n")
 		}
 
 		n, err := jsonino.SchemaFrom(opt.outputType)
@@ -55,8 +69,28 @@ func generateRouteFromOptions(opt *Option) (string, string) {
 		}
 	}
 
-	return path, body
+	return path, strings.TrimPrefix(body+routeExamples(opt), "\n")
+
+}
 
+// routeExamples formats Option.Examples for the route output.
+func routeExamples(opt *Option) string {
+	out := ""
+	for i, ex := range opt.Examples {
+		out += "\n  Example " + exampleName(ex, i) + ":"
+		if ex.Summary != "" {
+			out += "  # " + ex.Summary
+		}
+		if ex.Input != nil {
+			b, _ := json.Marshal(ex.Input)
+			out += "\n    Input: " + string(b)
+		}
+		if ex.Output != nil {
+			b, _ := json.Marshal(exampleOutput(opt, ex.Output))
+			out += "\n    Response: " + string(b)
+		}
+	}
+	return out
 }
 
 func parseParametersAndFormDataForRoute(t reflect.Type) (
@@ -110,7 +144,7 @@ func parseParametersAndFormDataForRoute(t reflect.Type) (
 
 			formSchema += formatParam(field, name)
 
-			if field.Type == reflect.TypeOf((*multipart.FileHeader)(nil)) {
+			if field.Type == reflect.TypeOf((*multipart.FileHeader)(nil)) || isUploadType(field.Type) {
 				usesMultipart = true
 			}
 		}
@@ -150,3 +184,40 @@ func formatParam(field reflect.StructField, name string) string {
 
 	return fmt.Sprintf("%s=%s", n, typ)
 }
+
+// fiber params: :name, :name<int>, :name? and the greedy * / + (accessed as "*", "*1", "+2" ...)
+var routeParamRe = regexp.MustCompile(`:(\w+)(<[^>]*>)?\??|[*+]`)
+
+// templatePath converts fiber style parameters (/users/:id, /files/*) to URI templates
+// (/users/{id}, /files/{wildcard}) and returns the parameter names in order.
+func templatePath(path string) (string, []string) {
+	var names []string
+	counts := map[byte]int{}
+	tmpl := routeParamRe.ReplaceAllStringFunc(path, func(m string) string {
+		name := ""
+		if m[0] == ':' {
+			name = routeParamRe.FindStringSubmatch(m)[1]
+		} else {
+			counts[m[0]]++
+			name = pathParamName(m[:1] + strconv.Itoa(counts[m[0]]))
+		}
+		names = append(names, name)
+		return "{" + name + "}"
+	})
+	return tmpl, names
+}
+
+// pathParamName maps fiber greedy parameter names (path:"*", path:"+2") to template names.
+func pathParamName(name string) string {
+	if name == "" || (name[0] != '*' && name[0] != '+') {
+		return name
+	}
+	base := "wildcard"
+	if name[0] == '+' {
+		base = "plus"
+	}
+	if n := name[1:]; n != "" && n != "1" {
+		return base + n
+	}
+	return base
+}
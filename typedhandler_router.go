@@ -43,9 +43,11 @@ func (r *Server) TypedHandle(th Function) {
 		r.internalHandlerCache = append(r.internalHandlerCache, th)
 		return
 	}
+	opt.apiPath = r.versionedPath(opt)
+	path := opt.apiPath
 
 	if opt.CORS || (r.Config != nil && r.Config.Debug) {
-		r.Fiber.Add("OPTIONS", opt.Path, func(w *fiber.Ctx) error {
+		r.Fiber.Add("OPTIONS", path, func(w *fiber.Ctx) error {
 			addCORSHeaders(opt, w)
 			w.Status(http.StatusOK)
 			return nil
@@ -57,16 +59,17 @@ func (r *Server) TypedHandle(th Function) {
 		if req.fiber != nil {
 			req.loggerWith = req.Logger().With(
 				zap.String("method", req.fiber.Method()),
-				zap.String("path", opt.Path),
+				zap.String("path", path),
 				zap.String("ip", req.ClientIP()),
 			)
 
 			if opt.CORS || r.Config.Debug {
 				addCORSHeaders(opt, req.Fiber())
 			}
+			setDeprecationHeaders(opt, req.Fiber())
 		} else {
 			req.loggerWith = req.Logger().With(
-				zap.String("path", opt.Path),
+				zap.String("path", path),
 				zap.String("ip", req.ClientIP()),
 			)
 		}
@@ -75,10 +78,16 @@ func (r *Server) TypedHandle(th Function) {
 	}
 
 	r.FunctionCache = append(r.FunctionCache, th)
-	r.HandleRequestFunc(opt.Method, opt.Path, requestFn)
-
-	for _, m := range opt.SubMethod {
-		r.HandleRequestFunc(m, opt.Path, requestFn)
+	for _, m := range append([]string{opt.Method}, opt.SubMethod...) {
+		if opt.inputReflectPlan != nil && opt.inputReflectPlan.uploads {
+			r.enableStreamingUpload()
+			r.uploadRoutes = append(r.uploadRoutes, [2]string{m, path})
+		}
+		if opt.APIVersion != "" && r.versionByHeader() {
+			r.handleVersionedRequestFunc(m, path, opt.APIVersion, requestFn)
+		} else {
+			r.HandleRequestFunc(m, path, requestFn)
+		}
 	}
 }
 
@@ -129,7 +138,7 @@ func (r *Server) RegisteredFunctions() []*Option {
 	ho = append(ho, r.optionsCache...)
 
 	slices.SortFunc(ho, func(a, b *Option) int {
-		return cmp.Compare(a.Path, b.Path)
+		return cmp.Compare(a.RoutePath(), b.RoutePath())
 	})
 
 	return ho
@@ -0,0 +1,122 @@
+package allino
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const EventStream = "text/event-stream"
+
+// Stream is the typed sink passed to a streaming function.
+// Items are forwarded to the caller as soon as Send is called (SSE, MCP progress, CLI lines).
+// When no caller is listening (Function.Call, job workers), items are collected and returned as []U.
+type Stream[U any] struct {
+	r     *Runtime
+	sink  func(v any) error
+	items []U
+}
+
+func (s *Stream[U]) Send(v U) error {
+	if s.sink != nil {
+		return s.sink(v)
+	}
+	s.items = append(s.items, v)
+	return nil
+}
+
+func (s *Stream[U]) Context() context.Context {
+	return s.r.Context()
+}
+
+func NewStreamFunction[T, U any](option Option, handlefunc func(r *Runtime, input T, stream *Stream[U]) error) *GenericFunction[T, []U, error] {
+	if option.ContentType == "" {
+		option.ContentType = EventStream
+	}
+	option.streaming = true
+
+	return NewFunction(option, func(r *Runtime, input T) ([]U, error) {
+		st := &Stream[U]{
+			r:    r,
+			sink: r.memo.streamSink,
+		}
+		err := handlefunc(r, input, st)
+		return st.items, err
+	})
+}
+
+func (h Option) Streaming() bool {
+	return h.streaming
+}
+
+// serveEventStream runs fn after the handler returns and writes every streamed item as a SSE message.
+// fn gets a detached runtime, the fiber context is released before the body is written.
+func (r *Runtime) serveEventStream(options *Option, fn func(r *Runtime) error) {
+	r.fiber.Set("Content-Type", EventStream)
+	r.fiber.Set("Cache-Control", "no-cache")
+	r.fiber.Set("Connection", "keep-alive")
+	r.fiber.Set("X-Accel-Buffering", "no")
+	r.fiber.Status(options.ResponseStatusCode)
+
+	dr := r.detached()
+	r.fiber.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		dr.memo.streamSink = func(v any) error {
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			return writeSSE(w, "", buf)
+		}
+
+		err := fn(dr)
+		if !isReallyNil(err) {
+			buf, _ := json.Marshal(&APIError[error]{Err: normalizeError(err).(error)})
+			_ = writeSSE(w, "error", buf)
+			return
+		}
+		_ = writeSSE(w, "done", []byte("{}"))
+	})
+}
+
+func writeSSE(w *bufio.Writer, event string, data []byte) error {
+	if event != "" {
+		w.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		w.WriteString("data: ")
+		w.Write(line)
+		w.WriteString("\n")
+	}
+	w.WriteString("\n")
+	return w.Flush()
+}
+
+func acceptsEventStream(accept string) bool {
+	return strings.Contains(strings.ToLower(accept), EventStream)
+}
+
+func eventStreamErrorHandler(r *Runtime, options *Option, err error) {
+	if redir, ok := err.(FiberHandler); ok {
+		redir.HandleFiber(r.fiber)
+		return
+	}
+
+	status := options.ErrorStatusCode
+	if cerr, ok := err.(HttpError); ok && cerr.StatusCode() != 0 {
+		status = cerr.StatusCode()
+	}
+	if status == 0 {
+		status = http.StatusBadRequest
+	}
+
+	buf, _ := json.Marshal(&APIError[error]{Err: normalizeError(err).(error)})
+	r.fiber.Set("Content-Type", EventStream)
+	r.fiber.Status(status)
+	var out bytes.Buffer
+	bw := bufio.NewWriter(&out)
+	_ = writeSSE(bw, "error", buf)
+	_ = r.fiber.Send(out.Bytes())
+}
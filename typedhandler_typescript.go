@@ -0,0 +1,505 @@
+package allino
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wh-kuromai/jsonino"
+)
+
+var tsIdentRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// GenerateTypeScript returns TypeScript interfaces for the input/output/error types of the
+// registered functions, and a fetch based Client with one method per function.
+// Property names and required fields follow the jsonino schemas used by OpenAPI and MCP.
+func (s *Server) GenerateTypeScript() (string, error) {
+	g := &tsGenerator{
+		names:  map[reflect.Type]string{},
+		used:   map[string]bool{"Client": true, "ClientOptions": true, "AllinoError": true, "ErrorBody": true, "JobPending": true, "JobPendingError": true},
+		decls:  map[string]string{},
+		inline: map[reflect.Type]bool{},
+	}
+
+	methods := map[string]bool{"options": true, "call": true, "constructor": true}
+	var b strings.Builder
+	for _, h := range clientFunctions(s) {
+		g.method(&b, tsMethodName(methods, h.Options()), h)
+	}
+
+	names := make([]string, 0, len(g.decls))
+	for name := range g.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	out.WriteString("// Code generated by allino typescript. DO NOT EDIT.\n\n")
+	for _, name := range names {
+		out.WriteString(g.decls[name])
+		out.WriteString("\n")
+	}
+	out.WriteString(tsRuntime)
+	out.WriteString("\nexport class Client {\n")
+	out.WriteString("  constructor(public options: ClientOptions = {}) {}\n\n")
+	out.WriteString(b.String())
+	out.WriteString(tsCall)
+	out.WriteString("}\n")
+	return out.String(), nil
+}
+
+type tsGenerator struct {
+	names  map[reflect.Type]string
+	used   map[string]bool
+	decls  map[string]string
+	inline map[reflect.Type]bool
+}
+
+// tsMethodName returns the lowerCamelCase name of the operationId.
+func tsMethodName(used map[string]bool, opt *Option) string {
+	name := clientMethodName(used, opt)
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	delete(used, name)
+	name = string(r)
+	for i := 2; used[name]; i++ {
+		name = string(r) + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+func (g *tsGenerator) method(b *strings.Builder, name string, h Function) {
+	opt := h.Options()
+	wrapped := opt.ContentType == JSON && !opt.NoWrapJSON
+
+	out := clientOutputType(opt)
+	outSchema := jsoninoSchemaMap(h.OutputSchema())
+	if wrapped && out != opt.outputType {
+		outSchema = tsProperty(outSchema, "data")
+	}
+	outExpr := "unknown"
+	raw := isRawClientOutput(out) && opt.ContentType != JSON
+	if raw {
+		outExpr = "string"
+	} else if out != nil {
+		outExpr = g.typeExpr(outSchema, out)
+	}
+
+	errExpr := "ErrorBody"
+	if et := opt.errorType; et != nil && !opt.eiserror {
+		errSchema := jsoninoSchemaMap(h.ErrorSchema())
+		if wrapped && et.Kind() == reflect.Struct && strings.HasPrefix(et.Name(), "APIError[") {
+			errSchema = tsProperty(errSchema, "error")
+			et = et.Field(0).Type
+		}
+		if et.Kind() != reflect.Interface {
+			errExpr = g.typeExpr(errSchema, et)
+		}
+	}
+
+	in := opt.inputType
+	inExpr := ""
+	if in.Kind() != reflect.Interface {
+		inExpr = g.typeExpr(jsoninoSchemaMap(h.InputSchema()), in)
+	}
+
+	var bindings []string
+	tsBindings(in, "", &bindings)
+	p, _ := templatePath(opt.RoutePath())
+
+	fmt.Fprintf(b, "  /** %s */\n", tsComment(opt))
+	if inExpr == "" {
+		fmt.Fprintf(b, "  %s(init?: RequestInit): Promise<%s> {\n", name, outExpr)
+		fmt.Fprintf(b, "    return this.call<%s, %s>(%q, %q, {}, [], %t, %t, init);\n", outExpr, errExpr,
+			strings.ToUpper(opt.Method), p, wrapped, raw)
+	} else {
+		fmt.Fprintf(b, "  %s(input: %s, init?: RequestInit): Promise<%s> {\n", name, inExpr, outExpr)
+		fmt.Fprintf(b, "    return this.call<%s, %s>(%q, %q, input, [%s], %t, %t, init);\n", outExpr, errExpr,
+			strings.ToUpper(opt.Method), p, strings.Join(bindings, ", "), wrapped, raw)
+	}
+	b.WriteString("  }\n\n")
+}
+
+func tsComment(opt *Option) string {
+	s := strings.ToUpper(opt.Method) + " " + opt.RoutePath()
+	if opt.Summary != "" {
+		s += " " + opt.Summary
+	}
+	return strings.ReplaceAll(s, "*/", "*\\/")
+}
+
+// tsBindings lists [property, source, name] of the tagged input fields, the first tag found wins
+// as in getByStructField. Cookie and jwt fields are sent by the browser, not by the client.
+func tsBindings(t reflect.Type, prefix string, out *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		prop, inline, ok := tsPropertyName(sf)
+		if !ok {
+			continue
+		}
+		if !inline {
+			prop = prefix + prop
+		}
+
+		tagged := false
+		for _, k := range remoteTagOrder {
+			v, ok := sf.Tag.Lookup(k.String())
+			if !ok {
+				continue
+			}
+			if v == "" {
+				v = sf.Name
+			}
+			tagged = true
+			switch k {
+			case tagPath:
+				v = pathParamName(v)
+				fallthrough
+			case tagQuery, tagForm, tagPost, tagHeader:
+				*out = append(*out, fmt.Sprintf("[%q, %q, %q]", prop, k.String(), v))
+			}
+			break
+		}
+
+		if !tagged && sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if inline {
+				tsBindings(sf.Type, prefix, out)
+			} else {
+				tsBindings(sf.Type, prop+".", out)
+			}
+		}
+	}
+}
+
+// tsPropertyName returns the property name of a field as jsonino names it.
+func tsPropertyName(sf reflect.StructField) (name string, inline bool, ok bool) {
+	if slices.Contains(strings.Split(sf.Tag.Get("link"), ","), "passive") {
+		return "", false, false
+	}
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = sf.Name
+	}
+	return name, strings.Contains(opts, "inline"), true
+}
+
+// jsoninoSchemaMap decodes a jsonino schema into a JSON Schema map, nil if the type is not supported by jsonino.
+func jsoninoSchemaMap(schema *jsonino.Schema, err error) map[string]any {
+	if err != nil || schema == nil {
+		return nil
+	}
+	buf, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if json.Unmarshal(buf, &m) != nil {
+		return nil
+	}
+	return m
+}
+
+func tsProperty(schema map[string]any, name string) map[string]any {
+	props, _ := schema["properties"].(map[string]any)
+	m, _ := props[name].(map[string]any)
+	return m
+}
+
+// typeExpr converts a jsonino schema to a TypeScript type, walking the Go type alongside it
+// so that named structs become interfaces. A nil schema falls back to reflection.
+func (g *tsGenerator) typeExpr(schema map[string]any, t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == tIOReader || t == tUploadPtr.Elem() || t == tFileHeader:
+		return "Blob"
+	case t == tTime:
+		return "string"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// base64 in JSON
+		return "string"
+	case t.Implements(tTextMarshaler) || reflect.PointerTo(t).Implements(tTextMarshaler):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		items, _ := schema["items"].(map[string]any)
+		e := g.typeExpr(items, t.Elem())
+		if strings.ContainsAny(e, " |") {
+			e = "(" + e + ")"
+		}
+		return e + "[]"
+	case reflect.Map:
+		return "Record<string, " + g.typeExpr(nil, t.Elem()) + ">"
+	case reflect.Struct:
+		if t.Name() != "" && !strings.Contains(t.Name(), "[") {
+			return g.named(t)
+		}
+		if g.inline[t] {
+			return "unknown"
+		}
+		g.inline[t] = true
+		defer delete(g.inline, t)
+		return g.objectExpr(schema, t, "")
+	}
+	return "unknown"
+}
+
+// named declares an interface for a named struct once and returns its (collision free) name.
+func (g *tsGenerator) named(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := clientIdent(t.Name())
+	if g.used[name] {
+		name = clientIdent(path.Base(t.PkgPath()) + "_" + t.Name())
+		base := name
+		for i := 2; g.used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+	}
+	g.used[name] = true
+	g.names[t] = name
+
+	schema := jsoninoSchemaMap(jsonino.SchemaFrom(t))
+	g.decls[name] = "export interface " + name + " " + g.objectExpr(schema, t, "") + "\n"
+	return name
+}
+
+func (g *tsGenerator) objectExpr(schema map[string]any, t reflect.Type, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	g.writeProperties(&b, schema, t, indent+"  ")
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func (g *tsGenerator) writeProperties(b *strings.Builder, schema map[string]any, t reflect.Type, indent string) {
+	props, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	if req, ok := schema["required"].([]any); ok {
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, inline, ok := tsPropertyName(sf)
+		if !ok {
+			continue
+		}
+		if inline {
+			ft := sf.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// jsonino merges the inline properties into this object
+				g.writeProperties(b, schema, ft, indent)
+			}
+			continue
+		}
+
+		prop, _ := props[name].(map[string]any)
+		expr := g.typeExpr(prop, sf.Type)
+		if strings.HasPrefix(expr, "{") {
+			expr = strings.ReplaceAll(expr, "\n", "\n"+indent)
+		}
+
+		_, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		optional := !required[name] && (sf.Type.Kind() == reflect.Ptr ||
+			strings.Contains(opts, "omitempty") || strings.Contains(opts, "omitzero"))
+
+		key := name
+		if !tsIdentRe.MatchString(key) {
+			key = strconv.Quote(key)
+		}
+		if optional {
+			key += "?"
+		}
+		fmt.Fprintf(b, "%s%s: %s;\n", indent, key, expr)
+	}
+}
+
+const tsRuntime = `export interface ClientOptions {
+  baseURL?: string;
+  /** sent as "Authorization: Bearer" */
+  token?: string | (() => string | undefined);
+  /** sent as "X-CSRF-Token", required with the login cookie for Auth: "writable" functions */
+  csrfToken?: string | (() => string | undefined);
+  headers?: Record<string, string>;
+  /** default "include", cookie fields are sent by the browser */
+  credentials?: RequestCredentials;
+  fetch?: typeof fetch;
+  /** retry interval while a job is pending (202), default 1000ms */
+  pollInterval?: number;
+  /** throw JobPendingError after this many ms, default 60000, 0 disables polling */
+  pollTimeout?: number;
+}
+
+export interface ErrorBody {
+  code?: string;
+  msg?: string;
+}
+
+export class AllinoError<E = ErrorBody> extends Error {
+  constructor(public readonly status: number, public readonly error: E) {
+    super((error as { msg?: string })?.msg || "HTTP " + status);
+  }
+}
+
+export interface JobPending {
+  jobid: string;
+  msg?: string;
+}
+
+export class JobPendingError extends AllinoError<JobPending> {}
+
+type Binding = [property: string, source: "path" | "query" | "form" | "post" | "header", name: string];
+
+function valueOf(input: any, property: string): any {
+  let v = input;
+  for (const k of property.split(".")) {
+    if (v == null) return undefined;
+    v = v[k];
+  }
+  return v;
+}
+
+function valueString(v: any): string {
+  return v instanceof Date ? v.toISOString() : String(v);
+}
+
+function resolve(v: string | (() => string | undefined) | undefined): string | undefined {
+  return typeof v === "function" ? v() : v;
+}
+
+function parseJSON(text: string): any {
+  try {
+    return JSON.parse(text);
+  } catch {
+    return undefined;
+  }
+}
+
+const sleep = (ms: number) => new Promise((resolve) => setTimeout(resolve, ms));
+`
+
+const tsCall = `  protected async call<T, E = ErrorBody>(method: string, path: string, input: any, bindings: Binding[],
+    wrapped: boolean, raw: boolean, init?: RequestInit): Promise<T> {
+    const query = new URLSearchParams();
+    const form: [string, string | Blob][] = [];
+    const headers: Record<string, string> = { Accept: "application/json", ...this.options.headers };
+    let body: BodyInit | undefined;
+
+    for (const [property, source, name] of bindings) {
+      const v = valueOf(input, property);
+      if (v === undefined || v === null) continue;
+      switch (source) {
+        case "path":
+          path = path.replace("{" + name + "}", encodeURIComponent(valueString(v)));
+          break;
+        case "query":
+          for (const x of Array.isArray(v) ? v : [v]) query.append(name, valueString(x));
+          break;
+        case "form":
+          form.push([name, v instanceof Blob ? v : valueString(v)]);
+          break;
+        case "header":
+          headers[name] = valueString(v);
+          break;
+        case "post":
+          if (name === "raw") {
+            body = v;
+            headers["Content-Type"] = "application/octet-stream";
+          } else if (name === "xml" || name === "msgpack") {
+            throw new Error(name + " request bodies are not supported by the client");
+          } else {
+            // JSON is valid YAML
+            body = JSON.stringify(v);
+            headers["Content-Type"] = name === "yaml" ? "application/yaml" : "application/json";
+          }
+          break;
+      }
+    }
+
+    if (body === undefined && form.length > 0) {
+      if (form.some(([, v]) => v instanceof Blob)) {
+        // values first, files last: the server streams the last file part
+        const fd = new FormData();
+        for (const [k, v] of form) if (!(v instanceof Blob)) fd.append(k, v);
+        for (const [k, v] of form) if (v instanceof Blob) fd.append(k, v);
+        body = fd;
+      } else {
+        body = new URLSearchParams(form as [string, string][]);
+      }
+    }
+
+    const token = resolve(this.options.token);
+    if (token) headers["Authorization"] = "Bearer " + token;
+    const csrf = resolve(this.options.csrfToken);
+    if (csrf) headers["X-CSRF-Token"] = csrf;
+
+    // drop unset optional parameters
+    path = path.replace(/\/?\{[^}/]+\}/g, "");
+    const qs = query.toString();
+    const url = (this.options.baseURL ?? "").replace(/\/$/, "") + path + (qs ? "?" + qs : "");
+    const doFetch = this.options.fetch ?? fetch;
+    const interval = this.options.pollInterval ?? 1000;
+    const deadline = Date.now() + (this.options.pollTimeout ?? 60000);
+
+    for (;;) {
+      const res = await doFetch(url, { method, headers, body, credentials: this.options.credentials ?? "include", ...init });
+      const text = await res.text();
+      const json = parseJSON(text);
+
+      if (res.status === 202 && json?.error?.jobid) {
+        if (Date.now() + interval > deadline) throw new JobPendingError(res.status, json.error);
+        await sleep(interval);
+        continue;
+      }
+      if (!res.ok) throw new AllinoError<E>(res.status, json?.error ?? json ?? { msg: res.statusText });
+      if (raw) return text as T;
+      return (wrapped ? json?.data : json) as T;
+    }
+  }
+`
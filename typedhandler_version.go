@@ -0,0 +1,108 @@
+package allino
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	VersioningPath   = "path"   // /<APIVersion><Path>
+	VersioningHeader = "header" // <Path>, selected by the Accept-Version header
+
+	defaultVersionHeader = "Accept-Version"
+)
+
+// RoutePath returns the path the function is mounted at, with the API version prefix in path versioning.
+func (h Option) RoutePath() string {
+	if h.apiPath != "" {
+		return h.apiPath
+	}
+	return h.Path
+}
+
+func (s *Server) versionByHeader() bool {
+	return s.Config != nil && strings.EqualFold(s.Config.Routing.Versioning, VersioningHeader)
+}
+
+func (s *Server) versionHeader() string {
+	if s.Config != nil && s.Config.Routing.VersionHeader != "" {
+		return s.Config.Routing.VersionHeader
+	}
+	return defaultVersionHeader
+}
+
+// versionedPath returns the mount path of opt: "/v1/users" for APIVersion "v1" and Path "/users".
+func (s *Server) versionedPath(opt *Option) string {
+	if opt.APIVersion == "" || s.versionByHeader() {
+		return opt.Path
+	}
+	return "/" + strings.Trim(opt.APIVersion, "/") + opt.Path
+}
+
+// sameVersion compares API versions ignoring case and a "v" prefix, "1" matches "v1".
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(strings.ToLower(a), "v") == strings.TrimPrefix(strings.ToLower(b), "v")
+}
+
+// acceptsVersion reports whether the request selects version, requests without the header
+// get Routing.DefaultVersion, or the first registered version when it is not set.
+func (s *Server) acceptsVersion(c *fiber.Ctx, version string) bool {
+	v := c.Get(s.versionHeader())
+	if v == "" {
+		if s.Config.Routing.DefaultVersion == "" {
+			return true
+		}
+		v = s.Config.Routing.DefaultVersion
+	}
+	return sameVersion(v, version)
+}
+
+// handleVersionedRequestFunc is HandleRequestFunc for header versioning: requests for
+// other versions are passed to the next route registered on the same path.
+func (s *Server) handleVersionedRequestFunc(method, pattern, version string, handlerfunc func(*Runtime)) {
+	h := s.requestHandler(handlerfunc)
+	header := s.versionHeader()
+	s.Fiber.Add(method, pattern, func(c *fiber.Ctx) error {
+		c.Vary(header)
+		if !s.acceptsVersion(c, version) {
+			return c.Next()
+		}
+		return h(c)
+	})
+}
+
+// setDeprecationHeaders sends the Deprecation and Sunset headers of deprecated functions.
+func setDeprecationHeaders(opt *Option, c *fiber.Ctx) {
+	if opt.Deprecated || !opt.Sunset.IsZero() {
+		c.Set("Deprecation", "true")
+	}
+	if !opt.Sunset.IsZero() {
+		c.Set("Sunset", opt.Sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// APIVersions returns the API versions of the registered functions.
+func (s *Server) APIVersions() []string {
+	var versions []string
+	for _, h := range s.FunctionCache {
+		v := h.Options().APIVersion
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, o := range versions {
+			if sameVersion(o, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			versions = append(versions, v)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
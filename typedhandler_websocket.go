@@ -0,0 +1,148 @@
+package allino
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	websocket "github.com/gofiber/websocket/v2"
+	"go.uber.org/zap"
+)
+
+var ErrUpgradeRequired = NewCodeError(426, "upgrade_required", "websocket upgrade required")
+
+// NewWebsocketFunction creates a function served over a WebSocket connection.
+// Path/query/header/cookie tags of T are read once from the handshake request,
+// then every text or binary frame is decoded as JSON over those values, validated,
+// and passed to handlefunc. The returned value is sent back as {"data": ...},
+// errors as {"error": ...}. A nil output sends nothing.
+func NewWebsocketFunction[T, U any](option Option, handlefunc func(r *Runtime, msg T) (U, error)) *GenericFunction[T, U, error] {
+	if option.ContentType == "" {
+		option.ContentType = JSON
+	}
+	option.Method = "GET"
+	option.websocket = true
+
+	rw := NewFunction(option, handlefunc)
+	rw.handler = func(r *Runtime) {
+		rw.serveWebsocket(r)
+	}
+	return rw
+}
+
+func (h Option) Websocket() bool {
+	return h.websocket
+}
+
+func (rw *GenericFunction[T, U, E]) serveWebsocket(r *Runtime) {
+	options := rw.options
+	r.cache.req_type = REQUEST_WS
+
+	if !websocket.IsWebSocketUpgrade(r.fiber) {
+		r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, ErrUpgradeRequired)
+		return
+	}
+
+	base := rw.NewInputWithDefault()
+	if !IsAny[T]() {
+		// the handshake only carries part of the message, validation runs per frame.
+		var err error
+		if reflect.TypeOf(base).Kind() == reflect.Pointer {
+			err = r.getAllWithValidate(base, options.inputReflectPlan, false)
+		} else {
+			err = r.getAllWithValidate(&base, options.inputReflectPlan, false)
+		}
+		if err != nil {
+			r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, err)
+			return
+		}
+	}
+
+	// resolve login and client ip while the handshake request is still available.
+	r.User()
+	r.ClientIP()
+
+	if err := r.enforceAuth(options); err != nil {
+		r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, err)
+		return
+	}
+	if err := r.enforceACL(options, base); err != nil && !errors.Is(err, ErrACLVariableMissing) {
+		r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, err)
+		return
+	}
+
+	err := websocket.New(func(conn *websocket.Conn) {
+		rw.serveWebsocketConn(r, base, conn)
+	}, r.server.websocketConfig())(r.fiber)
+	if err != nil {
+		r.errorJSON(options.ErrorStatusCode, options.NoWrapJSON, options.eiserror, err)
+	}
+}
+
+func (rw *GenericFunction[T, U, E]) serveWebsocketConn(r *Runtime, base T, conn *websocket.Conn) {
+	defer conn.Close()
+
+	for {
+		mt, buf, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if mt != websocket.TextMessage && mt != websocket.BinaryMessage {
+			continue
+		}
+
+		// the fiber context is released after the upgrade.
+		mr := *r
+		mr.fiber = nil
+		mr.memo = requestMemo{}
+
+		output, err := rw.websocketMessage(&mr, base, buf)
+
+		var reply any
+		if !isReallyNil(err) {
+			reply = normalizeError(err)
+			if !rw.options.NoWrapJSON {
+				reply = &APIError[error]{Err: reply.(error)}
+			}
+		} else if isReallyNil(output) {
+			continue
+		} else if rw.options.NoWrapJSON {
+			reply = output
+		} else {
+			reply = &APIResponse[any]{output}
+		}
+
+		buf, err = json.Marshal(reply)
+		if err != nil {
+			r.Logger().Warn("websocket reply marshal error", zap.Error(err))
+			continue
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, buf); err != nil {
+			return
+		}
+	}
+}
+
+func (rw *GenericFunction[T, U, E]) websocketMessage(r *Runtime, base T, buf []byte) (output U, err error) {
+	msg := base
+	if v := reflect.ValueOf(base); v.Kind() == reflect.Pointer && !v.IsNil() {
+		cp := reflect.New(v.Elem().Type())
+		cp.Elem().Set(v.Elem())
+		msg = cp.Interface().(T)
+	}
+
+	if err := json.Unmarshal(buf, &msg); err != nil {
+		return output, NewCodeError(400, "invalid_message", err.Error())
+	}
+
+	if !IsAny[T]() && !r.config.System.DisableValidator {
+		if err := r.server.Validator.Struct(msg); err != nil {
+			return output, ErrValidationFailed
+		}
+	}
+	if err := rw.enforce(r, msg); err != nil {
+		return output, err
+	}
+
+	return rw.call_internal(r, msg, false)
+}
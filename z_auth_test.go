@@ -9,7 +9,7 @@ import (
 
 	"github.com/wh-kuromai/allino"
 	"github.com/wh-kuromai/allino/alltest"
-	_ "github.com/wh-kuromai/allino/example/test/handlers"
+	"github.com/wh-kuromai/allino/example/test/handlers"
 )
 
 func TestAuthCSRF_Success(t *testing.T) {
@@ -78,6 +78,43 @@ func TestAuthCSRF_Unauthorized_NoCSRF(t *testing.T) {
 	}
 }
 
+func TestAuthOption(t *testing.T) {
+	post := func(withCookie, withCSRF bool) (int, string) {
+		form := url.Values{}
+		form.Set("message", "hello")
+		req := httptest.NewRequest("POST", "/test/authwritable", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		fakeReq := alltest.NewTestRequest(s)
+		if withCookie {
+			req.AddCookie(alltest.FiberToHTTPCookie(allino.IssueLoginCookie(fakeReq, "testuser", "Test User")))
+		}
+		if withCSRF {
+			req.Header.Set("X-CSRF-Token", allino.IssueCSRFToken(fakeReq, "testuser"))
+		}
+
+		w, _ := s.Fiber.Test(req, -1)
+		buf, _ := io.ReadAll(w.Body)
+		return w.StatusCode, string(buf)
+	}
+
+	if status, body := post(false, false); status != 401 || !strings.Contains(body, "login_required") {
+		t.Errorf("expected 401 login_required, got %d %s", status, body)
+	}
+	if status, body := post(true, false); status != 403 || !strings.Contains(body, "csrf_token_required") {
+		t.Errorf("expected 403 csrf_token_required, got %d %s", status, body)
+	}
+	if status, body := post(true, true); status != 200 || !strings.Contains(body, "testuser") {
+		t.Errorf("expected 200, got %d %s", status, body)
+	}
+
+	// server side calls, e.g. from cron or jobs, have no login and are trusted
+	out, err := handlers.AuthWritableFunction.Call(allino.NewRuntime(s, nil), &handlers.AuthCSRFInput{Message: "hello"})
+	if err != nil || out.Echo != "hello" {
+		t.Errorf("expected Call without a login, got %#v %v", out, err)
+	}
+}
+
 func TestCORSOptionsResponse(t *testing.T) {
 	req := httptest.NewRequest("OPTIONS", "/test/cors", nil)
 	w, _ := s.Fiber.Test(req, -1)
@@ -0,0 +1,87 @@
+package allino_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func postBody(t *testing.T, ct string, body []byte) (int, []byte) {
+	req := httptest.NewRequest("POST", "/test/body", bytes.NewReader(body))
+	req.Header.Set("Content-Type", ct)
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, buf
+}
+
+func TestPostBodyFormats(t *testing.T) {
+	packed, err := msgpack.Marshal(map[string]any{"name": "packed", "count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		ct   string
+		body []byte
+		name string
+	}{
+		{allino.JSON, []byte(`{"name":"json","count":1}`), "json"},
+		{allino.XML, []byte(`<BodyItem><name>xml</name><count>1</count></BodyItem>`), "xml"},
+		{allino.YAML, []byte("name: yaml\ncount: 1\n"), "yaml"},
+		{allino.MsgPack, packed, "packed"},
+	}
+
+	for _, c := range cases {
+		status, buf := postBody(t, c.ct, c.body)
+		if status != 200 {
+			t.Fatalf("%s: expected status 200, got %d %s", c.ct, status, buf)
+		}
+		var resp allino.APIResponse[handlers.BodyAPIOutput]
+		if err := json.Unmarshal(buf, &resp); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", c.ct, err)
+		}
+		if resp.Data.Name != c.name {
+			t.Errorf("%s: expected name %s, got %s", c.ct, c.name, resp.Data.Name)
+		}
+	}
+}
+
+func TestPostBodyErrors(t *testing.T) {
+	status, buf := postBody(t, allino.JSON, []byte(`{"name":`))
+	if status != 400 || !strings.Contains(string(buf), `"invalid_body"`) || !strings.Contains(string(buf), `"format": "json"`) {
+		t.Errorf("expected invalid_body error, got %d %s", status, buf)
+	}
+
+	status, buf = postBody(t, "text/csv", []byte("name,count"))
+	if status != 415 || !strings.Contains(string(buf), `"unsupported_media_type"`) {
+		t.Errorf("expected unsupported_media_type error, got %d %s", status, buf)
+	}
+
+	status, buf = postBody(t, allino.JSON, []byte(`{"name":"`+strings.Repeat("x", 300)+`"}`))
+	if status != 413 || !strings.Contains(string(buf), `"body_too_large"`) {
+		t.Errorf("expected body_too_large error, got %d %s", status, buf)
+	}
+}
+
+func TestPostBodyOpenAPI(t *testing.T) {
+	op := s.GenerateOpenAPI().Paths["/test/body"]["post"]
+	if op == nil || op.RequestBody == nil {
+		t.Fatalf("expected request body in OpenAPI")
+	}
+	for _, ct := range []string{allino.JSON, allino.XML, allino.YAML, allino.MsgPack} {
+		if op.RequestBody.Content[ct] == nil || op.RequestBody.Content[ct].Schema == nil {
+			t.Errorf("expected %s request body schema", ct)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package allino
 
 import (
+	"encoding/json"
+	"io"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 type aclBookInput struct {
@@ -146,3 +150,385 @@ m = r.dom == p.dom && r.sub == p.sub && r.obj == p.obj && r.act == p.act
 		t.Fatalf("expected 403, got %d", resp.StatusCode)
 	}
 }
+
+func TestCasbinACLOpenAPIDocs(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+
+	model := `[request_definition]
+r = dom, sub, obj, act
+
+[policy_definition]
+p = dom, sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.dom == p.dom && r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+	policy := "p, acme, alice, apidocs, read\n"
+
+	if err := os.WriteFile(modelPath, []byte(model), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(&Config{
+		Casbin: CasbinConfig{
+			ModelPath:  modelPath,
+			PolicyPath: policyPath,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := openapiDocsConfig()
+	saved := *config
+	*config = OpenAPIDocsConfig{Enable: true, ACLResource: "apidocs"}
+	defer func() { *config = saved }()
+	registerOpenAPIDocsHandlers(s)
+
+	r := NewRuntime(s, nil)
+	for user, expected := range map[string]int{"alice": 200, "bob": 403} {
+		token := IssueAccessToken(r, user, user, map[string]any{"tenant": "acme"})
+		req := httptest.NewRequest("GET", "/openapi.json", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("%s: expected %d, got %d", user, expected, resp.StatusCode)
+		}
+	}
+}
+
+type aclMCPBookInput struct {
+	BookID string `json:"book_id" acl:"book"`
+}
+
+// aclMCPSession initializes an MCP session for the bearer token and returns its id.
+func aclMCPSession(t *testing.T, s *Server, token string) string {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":0,"method":"initialize"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Header.Get(mcpSessionHeader)
+}
+
+func TestCasbinACLMCPTool(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+
+	model := `[request_definition]
+r = dom, sub, obj, act
+
+[policy_definition]
+p = dom, sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.dom == p.dom && r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+	policy := "p, acme, alice, books/1, read\np, acme, alice, reports, read\n"
+
+	if err := os.WriteFile(modelPath, []byte(model), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(&Config{
+		Casbin: CasbinConfig{
+			ModelPath:  modelPath,
+			PolicyPath: policyPath,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	functionListLen := len(FunctionList)
+	s.TypedHandle(NewFunction(
+		Option{
+			Name:        "acl_mcp_reports",
+			MCP:         "tool",
+			ContentType: JSON,
+			ACLResource: "reports",
+			ACLAction:   "read",
+		},
+		func(r *Runtime, input aclMCPBookInput) (string, error) {
+			return "reports", nil
+		},
+	))
+	fn := NewFunction(
+		Option{
+			Name:        "acl_mcp_book",
+			MCP:         "tool",
+			ContentType: JSON,
+			ACLResource: "books/{book}",
+			ACLAction:   "read",
+		},
+		func(r *Runtime, input aclMCPBookInput) (*aclMCPBookInput, error) {
+			return &input, nil
+		},
+	)
+	defer func() {
+		FunctionList = FunctionList[:functionListLen]
+	}()
+	s.TypedHandle(fn)
+	registerMCPHandlers(s)
+
+	r := NewRuntime(s, nil)
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"acl_mcp_book","arguments":{"book_id":"1"}}}`
+	for user, denied := range map[string]bool{"alice": false, "bob": true} {
+		token := IssueAccessToken(r, user, user, map[string]any{"tenant": "acme"})
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(mcpSessionHeader, aclMCPSession(t, s, token))
+
+		resp, err := s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out struct {
+			Result struct {
+				IsError bool `json:"isError"`
+			} `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Result.IsError != denied {
+			t.Fatalf("%s: expected isError=%v", user, denied)
+		}
+
+		// templated resources are listed and checked on the call
+		req = httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(mcpSessionHeader, aclMCPSession(t, s, token))
+		resp, err = s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list struct {
+			Result struct {
+				Tools []struct {
+					Name string `json:"name"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		listed := map[string]bool{}
+		for _, tool := range list.Result.Tools {
+			listed[tool.Name] = true
+		}
+		if !listed["acl_mcp_book"] || listed["acl_mcp_reports"] != !denied {
+			t.Fatalf("%s: unexpected tools %v", user, listed)
+		}
+	}
+}
+
+func TestCasbinACLMCPJobPoll(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+
+	model := `[request_definition]
+r = dom, sub, obj, act
+
+[policy_definition]
+p = dom, sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.dom == p.dom && r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+	policy := "p, acme, alice, books/1, read\n"
+
+	if err := os.WriteFile(modelPath, []byte(model), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	functionListLen := len(FunctionList)
+	defer func() {
+		FunctionList = FunctionList[:functionListLen]
+	}()
+	fn := NewFunction(
+		Option{
+			Name:        "acl_mcp_job",
+			MCP:         "tool",
+			ContentType: JSON,
+			JobMode:     "dispatch",
+			ACLResource: "books/{book}",
+			ACLAction:   "read",
+		},
+		func(r *Runtime, input aclMCPBookInput) (*aclMCPBookInput, error) {
+			return &input, nil
+		},
+	)
+	s := NewTestServer(&Config{
+		SQL: SQLConfig{Driver: "sqlite"},
+		Casbin: CasbinConfig{
+			ModelPath:  modelPath,
+			PolicyPath: policyPath,
+		},
+	})
+
+	r := NewRuntime(s, nil)
+	call := func(user, params string) map[string]any {
+		t.Helper()
+		token := IssueAccessToken(r, user, user, map[string]any{"tenant": "acme"})
+		body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":` + params + `}`
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(mcpSessionHeader, aclMCPSession(t, s, token))
+		resp, err := s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out struct {
+			Result map[string]any `json:"result"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Result
+	}
+
+	meta, _ := call("alice", `{"name":"acl_mcp_job","arguments":{"book_id":"1"}}`)["_meta"].(map[string]any)
+	jobid, _ := meta["allino/jobid"].(string)
+	if jobid == "" {
+		t.Fatalf("expected a pending job, got %#v", meta)
+	}
+	poll := `{"name":"acl_mcp_job","_meta":{"allino/jobid":"` + jobid + `"}}`
+
+	var result map[string]any
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if result = call("alice", poll); result["structuredContent"] != nil {
+			break
+		}
+	}
+	if result["structuredContent"] == nil {
+		t.Fatalf("alice: expected the job result, got %#v", result)
+	}
+
+	// the job id is known, but bob is not allowed to read the book.
+	result = call("bob", poll)
+	if result["isError"] != true || result["structuredContent"] != nil {
+		t.Fatalf("bob: expected the poll to be denied, got %#v", result)
+	}
+
+	// server side callers have no login and read the result as before.
+	out, err := fn.JobResult(NewRuntime(s, nil), jobid)
+	if err != nil || out == nil || out.BookID != "1" {
+		t.Fatalf("expected the job result without a login, got %#v %v", out, err)
+	}
+}
+
+func TestCasbinACLMCPFiles(t *testing.T) {
+	saved := *MCPExtension.Config
+	defer func() { *MCPExtension.Config = saved }()
+
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "model.conf")
+	policyPath := filepath.Join(dir, "policy.csv")
+	prompts := filepath.Join(dir, "prompts")
+	resources := filepath.Join(dir, "resources")
+	os.MkdirAll(prompts, 0o755)
+	os.MkdirAll(resources, 0o755)
+	for _, name := range []string{"open", "secret"} {
+		os.WriteFile(filepath.Join(prompts, name+".md"), []byte("prompt "+name), 0o600)
+		os.WriteFile(filepath.Join(resources, name+".md"), []byte("resource "+name), 0o600)
+	}
+
+	model := `[request_definition]
+r = dom, sub, obj, act
+
+[policy_definition]
+p = dom, sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.dom == p.dom && r.sub == p.sub && r.obj == p.obj && r.act == p.act
+`
+	policy := "p, acme, alice, files/open, read\np, acme, alice, files/open.md, read\n"
+	if err := os.WriteFile(modelPath, []byte(model), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, _ := json.Marshal(map[string]any{"mcp": map[string]any{
+		"promptDirs":       []string{prompts},
+		"resourceDirs":     []string{resources},
+		"filesACLResource": "files/{name}",
+		"filesACLAction":   "read",
+	}})
+	s := NewTestServer(&Config{
+		ConfigBytes: config,
+		SQL:         SQLConfig{Driver: "sqlite"},
+		Casbin: CasbinConfig{
+			ModelPath:  modelPath,
+			PolicyPath: policyPath,
+		},
+	})
+
+	token := IssueAccessToken(NewRuntime(s, nil), "alice", "alice", map[string]any{"tenant": "acme"})
+	post := func(body string) string {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(mcpSessionHeader, aclMCPSession(t, s, token))
+		resp, err := s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, _ := io.ReadAll(resp.Body)
+		return string(buf)
+	}
+
+	if body := post(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`); !strings.Contains(body, `"open"`) || strings.Contains(body, `"secret"`) {
+		t.Errorf("unexpected prompts %s", body)
+	}
+	if body := post(`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`); !strings.Contains(body, "open.md") || strings.Contains(body, "secret.md") {
+		t.Errorf("unexpected resources %s", body)
+	}
+	if body := post(`{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"secret"}}`); strings.Contains(body, "prompt secret") {
+		t.Errorf("expected the secret prompt to be denied, got %s", body)
+	}
+	if body := post(`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"allino://resource/secret.md"}}`); strings.Contains(body, "resource secret") {
+		t.Errorf("expected the secret resource to be denied, got %s", body)
+	}
+	if body := post(`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"allino://resource/open.md"}}`); !strings.Contains(body, "resource open") {
+		t.Errorf("expected the open resource, got %s", body)
+	}
+}
@@ -40,6 +40,17 @@ func TestCLI_OpenAPI(t *testing.T) {
 	assert.Contains(t, output, "user:")
 }
 
+func TestCLI_RouteCheck(t *testing.T) {
+	app := allino.NewCLI(nil)
+	app.Command.SetArgs([]string{"route", "--check"})
+
+	output := captureStdout(func() {
+		app.Run()
+	})
+
+	assert.Contains(t, output, "no route conflicts")
+}
+
 func TestCLI_MCP(t *testing.T) {
 	app := allino.NewCLI(nil)
 	app.Command.SetArgs([]string{"mcp"})
@@ -0,0 +1,96 @@
+package allino_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/alltest"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func TestRemoteFunction(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go s.Fiber.Listener(ln)
+	defer ln.Close()
+
+	remote := &allino.RemoteServer{BaseURL: "http://" + ln.Addr().String()}
+	r := alltest.NewTestRequest(s)
+
+	echo := allino.NewRemoteFunction[*handlers.EchoAPIInput, *handlers.EchoAPIOutput](remote,
+		allino.Option{Path: "/test/echo"})
+	out, err := echo.Call(r, &handlers.EchoAPIInput{Echo: "remote"})
+	require.NoError(t, err)
+	assert.Equal(t, "remote", out.Echo)
+	assert.Equal(t, "", echo.Options().Path, "remote functions are not routed locally")
+
+	pathparam := allino.NewRemoteFunction[*handlers.PathParamAPIInput, *handlers.PathParamAPIOutput](remote,
+		allino.Option{Path: "/test/pathparam/:mypath", Method: "POST"})
+	pout, err := pathparam.Call(r, &handlers.PathParamAPIInput{Mypath: "abc", Myform: "f"})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", pout.Path)
+	assert.Equal(t, "f", pout.Form)
+
+	failing := allino.NewRemoteFunction[*handlers.ErrorTestAPIInput, *handlers.ErrorTestAPIOutput](remote,
+		allino.Option{Path: "/test/error"})
+	_, err = failing.Call(r, &handlers.ErrorTestAPIInput{Mode: "code"})
+	var ae *allino.Error
+	require.True(t, errors.As(err, &ae), "expected *allino.Error, got %v", err)
+	assert.Equal(t, 403, ae.Status)
+	assert.Equal(t, "FORBIDDEN", ae.Code)
+}
+
+func TestCLI_Client(t *testing.T) {
+	dir := t.TempDir()
+	app := allino.NewCLI(nil)
+	app.Command.SetArgs([]string{"client", "--package", "testclient", "-o", filepath.Join(dir, "client.go")})
+	captureStdout(func() {
+		app.Run()
+	})
+
+	buf, err := os.ReadFile(filepath.Join(dir, "client.go"))
+	require.NoError(t, err)
+	src := string(buf)
+
+	assert.Contains(t, src, "package testclient")
+	assert.Contains(t, src, "func (c *Client) RuntimeGetTestEcho(ctx context.Context, in *EchoAPIInput) (*EchoAPIOutput, error)")
+	assert.Contains(t, src, `req.addQuery("echo", in.Echo)`)
+	assert.Contains(t, src, `req.setPath("mypath", in.Mypath)`)
+	assert.Contains(t, src, `newClientRequest("GET", "/test/pathparam/{mypath}")`)
+	assert.Contains(t, src, `req.setBody("auto", in.Item)`)
+	assert.NotContains(t, src, "StreamCount", "streaming functions are skipped")
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module testclient\n\ngo 1.21\n"), 0644))
+	cmd := exec.Command(gobin, "vet", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestCLI_TypeScript(t *testing.T) {
+	app := allino.NewCLI(nil)
+	app.Command.SetArgs([]string{"typescript"})
+	src := captureStdout(func() {
+		app.Run()
+	})
+
+	assert.Contains(t, src, "export interface EchoAPIOutput {\n  status: string;\n  echo?: string;\n  startAt: string;\n}")
+	assert.Contains(t, src, "export interface BodyAPIInput {\n  Item: BodyItem;\n}")
+	assert.Contains(t, src, `runtimeGetTestEcho(input: EchoAPIInput, init?: RequestInit): Promise<EchoAPIOutput>`)
+	assert.Contains(t, src, `"/test/pathparam/{mypath}", input, [["Mypath", "path", "mypath"], ["Myform", "form", "myform"]], true, false, init)`)
+	assert.Contains(t, src, `runtimeGetTestPing(init?: RequestInit): Promise<PingOutput>`)
+	assert.Contains(t, src, `headers["X-CSRF-Token"] = csrf`)
+	assert.Contains(t, src, "export class JobPendingError")
+	assert.NotContains(t, src, "X-Total", "response header fields are not part of the body")
+}
@@ -0,0 +1,133 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func TestGroupAPI(t *testing.T) {
+	req := httptest.NewRequest("POST", "/test/group/hello", strings.NewReader("name=yotsuba"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w, _ := s.Fiber.Test(req, -1)
+	bodybuf, _ := io.ReadAll(w.Body)
+	if w.StatusCode != 200 {
+		t.Fatalf("Expected status 200, got %d: %s", w.StatusCode, bodybuf)
+	}
+	var resp allino.APIResponse[handlers.GroupHelloOutput]
+	if err := json.Unmarshal(bodybuf, &resp); err != nil {
+		t.Fatalf("JSON parse error: %v", err)
+	}
+	if resp.Data.Hello != "yotsuba" {
+		t.Errorf("Expected hello=yotsuba, got %q", resp.Data.Hello)
+	}
+
+	req = httptest.NewRequest("POST", "/test/group/hello", strings.NewReader("name=yotsuba"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Group-Deny", "1")
+	w, _ = s.Fiber.Test(req, -1)
+	if w.StatusCode != 403 {
+		t.Errorf("Expected the group request handler to answer 403, got %d", w.StatusCode)
+	}
+
+	op := s.GenerateOpenAPI().Paths["/test/group/hello"]["post"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	if len(op.Tags) != 1 || op.Tags[0] != "test/group" {
+		t.Errorf("Expected group tag, got %v", op.Tags)
+	}
+}
+
+func TestGroupNested(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	var order []string
+	handler := func(name string) func(r *allino.Runtime, input any) (bool, error) {
+		return func(r *allino.Runtime, input any) (bool, error) {
+			order = append(order, name)
+			return false, nil
+		}
+	}
+
+	api := allino.Group("/api/", allino.Option{Method: "POST", ACLResource: "api", RequestHandler: handler("api")})
+	v1 := api.Group("v1", allino.Option{Method: "PUT"}).Use(handler("v1"))
+	fn := allino.NewFunction(
+		allino.Option{Group: v1, Path: "/items", RequestHandler: handler("own")},
+		func(r *allino.Runtime, in *handlers.GroupHelloInput) (*handlers.GroupHelloOutput, error) {
+			return &handlers.GroupHelloOutput{}, nil
+		})
+
+	opt := fn.Options()
+	if opt.Path != "/api/v1/items" || v1.Prefix() != "/api/v1" || v1.Name != "api/v1" {
+		t.Errorf("unexpected path %q, prefix %q, name %q", opt.Path, v1.Prefix(), v1.Name)
+	}
+	if opt.Method != "PUT" || opt.ACLResource != "api" {
+		t.Errorf("expected nested defaults, got method %q acl %q", opt.Method, opt.ACLResource)
+	}
+
+	if _, err := opt.RequestHandler(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "api,v1,own" {
+		t.Errorf("unexpected request handler order: %v", order)
+	}
+}
+
+func TestGroupSharedOptionsOnly(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	group := allino.Group("/shared", allino.Option{
+		Auth:        allino.AuthLogin,
+		ACLResource: "shared",
+		MCP:         "tool",
+		JobMode:     "async",
+		Job:         allino.JobOption{Cache: true},
+		APIVersion:  "v1",
+		NoWrapJSON:  true,
+	})
+	fn := allino.NewFunction(
+		allino.Option{Group: group, Name: "shared_member", Path: "/member"},
+		func(r *allino.Runtime, in *handlers.GroupHelloInput) (*handlers.GroupHelloOutput, error) {
+			return &handlers.GroupHelloOutput{}, nil
+		})
+
+	opt := fn.Options()
+	if opt.Auth != allino.AuthLogin || opt.ACLResource != "shared" {
+		t.Errorf("expected the auth and ACL of the group, got %q %q", opt.Auth, opt.ACLResource)
+	}
+	if opt.MCP != "" || opt.JobMode != "" || opt.Job.Cache || opt.APIVersion != "" || opt.NoWrapJSON {
+		t.Errorf("expected no MCP, job or version settings from the group, got %#v", opt)
+	}
+}
+
+func TestGroupACLActionFollowsResource(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	group := allino.Group("/books", allino.Option{ACLResource: "books", ACLAction: "write"})
+	own := allino.NewFunction(
+		allino.Option{Group: group, Name: "group_acl_own", Path: "/own", ACLResource: "shelves"},
+		func(r *allino.Runtime, in *handlers.GroupHelloInput) (*handlers.GroupHelloOutput, error) {
+			return &handlers.GroupHelloOutput{}, nil
+		})
+	if opt := own.Options(); opt.ACLResource != "shelves" || opt.ACLAction != "" {
+		t.Errorf("expected the member's resource without the group action, got %q %q", opt.ACLResource, opt.ACLAction)
+	}
+
+	shared := allino.NewFunction(
+		allino.Option{Group: group, Name: "group_acl_shared", Path: "/shared"},
+		func(r *allino.Runtime, in *handlers.GroupHelloInput) (*handlers.GroupHelloOutput, error) {
+			return &handlers.GroupHelloOutput{}, nil
+		})
+	if opt := shared.Options(); opt.ACLResource != "books" || opt.ACLAction != "write" {
+		t.Errorf("expected the group resource and action, got %q %q", opt.ACLResource, opt.ACLAction)
+	}
+}
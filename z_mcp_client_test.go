@@ -0,0 +1,260 @@
+package allino_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+)
+
+// mcpFiberTransport sends the requests of an MCP client to a test server.
+type mcpFiberTransport struct {
+	server  *allino.Server
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func (f *mcpFiberTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.headers = append(f.headers, req.Header.Clone())
+	f.mu.Unlock()
+	return f.server.Fiber.Test(req, -1)
+}
+
+func mcpFindTool(tools []allino.Function, name string) allino.Function {
+	for _, tool := range tools {
+		if tool.Options().Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+func TestMCPClientHTTP(t *testing.T) {
+	transport := &mcpFiberTransport{server: s}
+	client := allino.NewMCPClient("self", allino.MCPServerConfig{
+		URL:    "http://allino.test/mcp",
+		Token:  "test-token",
+		Header: map[string]string{"X-Test": "yes"},
+		Prefix: "self_",
+	})
+	client.Client = &http.Client{Transport: transport}
+	defer client.Close()
+
+	tools, err := client.Tools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo := mcpFindTool(tools, "self_mcp_echo")
+	if echo == nil {
+		t.Fatalf("self_mcp_echo not listed")
+	}
+	if echo.Options().Description != "Echoes a message for MCP tool tests." {
+		t.Errorf("unexpected description %q", echo.Options().Description)
+	}
+	schema, err := echo.InputSchema()
+	if err != nil || schema.Type() != "object" {
+		t.Fatalf("unexpected input schema %v %v", schema, err)
+	}
+
+	input, err := echo.UnmarshalInput([]byte(`{"message":"hello remote"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := echo.Handlefunc(allino.NewRuntime(s, nil), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := output.(map[string]any); out["echo"] != "hello remote" {
+		t.Errorf("unexpected output %#v", output)
+	}
+
+	if _, err := echo.Handlefunc(allino.NewRuntime(s, nil), map[string]any{}); err == nil {
+		t.Error("expected the validation error of the remote tool")
+	}
+
+	transport.mu.Lock()
+	first, last := transport.headers[0], transport.headers[len(transport.headers)-1]
+	transport.mu.Unlock()
+	if first.Get("Authorization") != "Bearer test-token" || first.Get("X-Test") != "yes" {
+		t.Errorf("unexpected headers %v", first)
+	}
+	if last.Get("Mcp-Session-Id") == "" || last.Get("Mcp-Protocol-Version") == "" {
+		t.Errorf("expected the session headers, got %v", last)
+	}
+}
+
+func TestMCPClientLazyTool(t *testing.T) {
+	client := allino.NewMCPClient("self", allino.MCPServerConfig{URL: "http://allino.test/mcp"})
+	client.Client = &http.Client{Transport: &mcpFiberTransport{server: s}}
+	defer client.Close()
+
+	echo := client.Tool("mcp_echo")
+	if echo.Options().Name != "mcp_echo" || echo.Options().Description != "" {
+		t.Fatalf("expected an unlisted tool, got %#v", echo.Options())
+	}
+	if _, err := echo.InputSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if echo.Options().Description == "" {
+		t.Error("expected the description after InputSchema")
+	}
+	if _, err := client.Tool("unknown").InputSchema(); err == nil {
+		t.Error("expected an error for an unknown tool")
+	}
+
+	result, err := client.CallTool(context.Background(), "unknown", nil)
+	if err == nil {
+		t.Errorf("expected an error for an unknown tool, got %#v", result)
+	}
+}
+
+// TestMCPClientStdioHelper is the stdio server started by the client tests.
+func TestMCPClientStdioHelper(t *testing.T) {
+	if os.Getenv("ALLINO_MCP_STDIO_HELPER") != "1" {
+		t.Skip("helper process of the stdio client tests")
+	}
+	s.ServeMCPStdio(os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+func mcpStdioServerConfig() allino.MCPServerConfig {
+	return allino.MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestMCPClientStdioHelper$"},
+		Env:     map[string]string{"ALLINO_MCP_STDIO_HELPER": "1"},
+	}
+}
+
+func TestMCPClientStdio(t *testing.T) {
+	client := allino.NewMCPClient("child", mcpStdioServerConfig())
+	defer client.Close()
+
+	result, err := client.CallTool(context.Background(), "mcp_echo", map[string]any{"message": "over stdio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || result.StructuredContent.(map[string]any)["echo"] != "over stdio" {
+		t.Errorf("unexpected result %#v", result)
+	}
+
+	// a closed client starts the process again
+	client.Close()
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) == 0 {
+		t.Error("expected the tools of the stdio server")
+	}
+}
+
+func TestMCPGatewayAuth(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	child := mcpStdioServerConfig()
+	child.Gateway = true
+	child.Prefix = "child_"
+	child.Tools = []string{"mcp_echo"}
+	child.Auth = allino.AuthLogin
+	config, _ := json.Marshal(map[string]any{"mcp": map[string]any{
+		"endpoint": "/gateway_mcp",
+		"servers":  map[string]any{"child": child},
+	}})
+	gateway := allino.NewTestServer(&allino.Config{
+		ConfigBytes: config,
+		SQL: allino.SQLConfig{
+			Driver: "sqlite",
+		},
+	})
+	defer allino.MCPServer("child").Close()
+
+	anonymous := allino.NewMCPClient("gateway", allino.MCPServerConfig{URL: "http://allino.test/gateway_mcp"})
+	anonymous.Client = &http.Client{Transport: &mcpFiberTransport{server: gateway}}
+	defer anonymous.Close()
+	if _, err := anonymous.CallTool(context.Background(), "child_mcp_echo", map[string]any{"message": "hello"}); err == nil {
+		t.Error("expected the call without a login to be denied")
+	}
+
+	token := allino.IssueAccessToken(allino.NewRuntime(gateway, nil), "alice", "Alice", nil)
+	user := allino.NewMCPClient("gateway", allino.MCPServerConfig{URL: "http://allino.test/gateway_mcp", Token: token})
+	user.Client = &http.Client{Transport: &mcpFiberTransport{server: gateway}}
+	defer user.Close()
+	if _, err := user.CallTool(context.Background(), "child_mcp_echo", map[string]any{"message": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMCPGateway(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	child := mcpStdioServerConfig()
+	child.Gateway = true
+	child.Prefix = "child_"
+	child.Tools = []string{"mcp_echo"}
+	config, _ := json.Marshal(map[string]any{"mcp": map[string]any{
+		"endpoint": "/gateway_mcp",
+		"servers":  map[string]any{"child": child},
+	}})
+	gateway := allino.NewTestServer(&allino.Config{
+		ConfigBytes: config,
+		SQL: allino.SQLConfig{
+			Driver: "sqlite",
+		},
+	})
+	defer allino.MCPServer("child").Close()
+
+	client := allino.NewMCPClient("gateway", allino.MCPServerConfig{URL: "http://allino.test/gateway_mcp"})
+	client.Client = &http.Client{Transport: &mcpFiberTransport{server: gateway}}
+	defer client.Close()
+
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Options().Name)
+	}
+	echo := client.Tool("child_mcp_echo")
+	if _, err := echo.InputSchema(); err != nil {
+		t.Fatalf("child_mcp_echo not re-exported: %v %v", names, err)
+	}
+	if _, err := client.Tool("child_mcp_image").InputSchema(); err == nil {
+		t.Error("expected only the tools listed in the config")
+	}
+
+	// middleware wraps the forwarded calls
+	called := 0
+	gateway.Use(func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+		if opt.Name != "child_mcp_echo" {
+			return next()
+		}
+		called++
+		if input.(map[string]any)["message"] == "denied" {
+			return nil, errors.New("denied by middleware")
+		}
+		return next()
+	})
+
+	result, err := client.CallTool(context.Background(), "child_mcp_echo", map[string]any{"message": "through the gateway"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StructuredContent.(map[string]any)["echo"] != "through the gateway" {
+		t.Errorf("unexpected result %#v", result)
+	}
+	if _, err := client.CallTool(context.Background(), "child_mcp_echo", map[string]any{"message": "denied"}); err == nil {
+		t.Error("expected the middleware to deny the call")
+	}
+	if called != 2 {
+		t.Errorf("expected the middleware on both calls, got %d", called)
+	}
+}
@@ -0,0 +1,84 @@
+package allino_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func mcpResult(t *testing.T, body string) map[string]any {
+	t.Helper()
+	resp := mcpRequest(t, "POST", body, mcpSession(t))
+	var out struct {
+		Result map[string]any `json:"result"`
+		Error  any            `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Error != nil {
+		t.Fatalf("unexpected error %#v", out.Error)
+	}
+	return out.Result
+}
+
+func TestMCPToolAnnotations(t *testing.T) {
+	result := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	tools := map[string]map[string]any{}
+	for _, v := range result["tools"].([]any) {
+		tool := v.(map[string]any)
+		tools[tool["name"].(string)] = tool
+	}
+
+	image := tools["mcp_image"]
+	annotations, _ := image["annotations"].(map[string]any)
+	if annotations["title"] != "Pixel" || annotations["readOnlyHint"] != true || annotations["destructiveHint"] != false {
+		t.Errorf("unexpected GET annotations %#v", annotations)
+	}
+	if image["outputSchema"] != nil {
+		t.Errorf("expected no outputSchema for image content, got %#v", image["outputSchema"])
+	}
+
+	annotations, _ = tools["mcp_report"]["annotations"].(map[string]any)
+	if annotations["title"] != "Report" || annotations["destructiveHint"] != false || annotations["readOnlyHint"] != nil {
+		t.Errorf("unexpected explicit annotations %#v", annotations)
+	}
+	if tools["mcp_echo"]["annotations"] != nil {
+		t.Errorf("expected no annotations without a route, got %#v", tools["mcp_echo"]["annotations"])
+	}
+}
+
+func TestMCPToolImageContent(t *testing.T) {
+	result := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"mcp_image","arguments":{}}}`)
+	if result["structuredContent"] != nil {
+		t.Errorf("expected no structuredContent, got %#v", result["structuredContent"])
+	}
+	content := result["content"].([]any)
+	if len(content) != 1 {
+		t.Fatalf("expected one content block, got %#v", content)
+	}
+	block := content[0].(map[string]any)
+	if block["type"] != "image" || block["mimeType"] != "image/png" || block["data"] != base64.StdEncoding.EncodeToString(handlers.MCPPixel) {
+		t.Errorf("unexpected image content %#v", block)
+	}
+}
+
+func TestMCPToolContentProvider(t *testing.T) {
+	result := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"mcp_report","arguments":{}}}`)
+	content := result["content"].([]any)
+	if len(content) != 3 {
+		t.Fatalf("expected 3 content blocks, got %#v", content)
+	}
+	if text := content[0].(map[string]any); text["type"] != "text" || text["text"] != "report" {
+		t.Errorf("unexpected text block %#v", text)
+	}
+	resource := content[1].(map[string]any)["resource"].(map[string]any)
+	if resource["uri"] != "allino://report.csv" || resource["text"] != "id,name\n1,alice" || resource["mimeType"] != "text/csv" {
+		t.Errorf("unexpected embedded resource %#v", resource)
+	}
+	if link := content[2].(map[string]any); link["type"] != "resource_link" || link["uri"] != "allino://users/1" {
+		t.Errorf("unexpected resource link %#v", link)
+	}
+}
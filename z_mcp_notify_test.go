@@ -0,0 +1,226 @@
+package allino_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wh-kuromai/allino"
+)
+
+// mcpStdioClient runs ServeMCPStdio over pipes and returns a line writer and reader.
+func mcpStdioClient(t *testing.T) (func(line string), func() map[string]any) {
+	t.Helper()
+	return mcpStdioClientOf(t, s)
+}
+
+func mcpStdioClientOf(t *testing.T, srv *allino.Server) (func(line string), func() map[string]any) {
+	t.Helper()
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- srv.ServeMCPStdio(inr, outw) }()
+	t.Cleanup(func() {
+		inw.Close()
+		<-done
+	})
+
+	lines := make(chan string, 16)
+	go func() {
+		reader := bufio.NewReader(outr)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	write := func(line string) {
+		io.WriteString(inw, line+"\n")
+	}
+	read := func() map[string]any {
+		t.Helper()
+		select {
+		case line := <-lines:
+			var msg map[string]any
+			if err := json.Unmarshal([]byte(line), &msg); err != nil {
+				t.Fatalf("invalid line %q: %v", line, err)
+			}
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an MCP message")
+			return nil
+		}
+	}
+	return write, read
+}
+
+func TestMCPResourceSubscribe(t *testing.T) {
+	write, read := mcpStdioClient(t)
+
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	caps := read()["result"].(map[string]any)["capabilities"].(map[string]any)
+	if caps["resources"].(map[string]any)["subscribe"] != true {
+		t.Errorf("expected resources.subscribe capability, got %#v", caps)
+	}
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"allino://users/42"}}`)
+	if msg := read(); msg["error"] != nil {
+		t.Fatalf("unexpected subscribe error %#v", msg)
+	}
+
+	// a function publishes the change, only the subscribed URI is delivered
+	s.NotifyMCPResourceChanged("allino://users/7")
+	write(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"mcp_touch_user","arguments":{"id":42}}}`)
+	msg := read()
+	if msg["method"] != "notifications/resources/updated" || msg["params"].(map[string]any)["uri"] != "allino://users/42" {
+		t.Fatalf("unexpected notification %#v", msg)
+	}
+	if msg := read(); msg["id"] != float64(3) {
+		t.Fatalf("unexpected tool response %#v", msg)
+	}
+
+	write(`{"jsonrpc":"2.0","id":4,"method":"resources/unsubscribe","params":{"uri":"allino://users/42"}}`)
+	if msg := read(); msg["error"] != nil {
+		t.Fatalf("unexpected unsubscribe error %#v", msg)
+	}
+	s.NotifyMCPResourceChanged("allino://users/42")
+	s.NotifyMCP("notifications/tools/list_changed", nil)
+	if msg := read(); msg["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("expected no update after unsubscribe, got %#v", msg)
+	}
+}
+
+func TestMCPResourceSubscribeChecksAccess(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	srv := allino.NewTestServer(&allino.Config{Debug: true, SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.TypedHandle(allino.NewFunction(
+		allino.Option{Name: "private_notes", ContentType: allino.JSON, MCP: "resource", Auth: allino.AuthLogin},
+		func(r *allino.Runtime, input *struct{}) (string, error) {
+			return "secret", nil
+		}))
+
+	write, read := mcpStdioClientOf(t, srv)
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	read()
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"allino://private_notes"}}`)
+	if msg := read(); msg["error"] == nil {
+		t.Fatalf("expected the subscription to be denied, got %#v", msg)
+	}
+	write(`{"jsonrpc":"2.0","id":3,"method":"resources/subscribe","params":{"uri":"allino://resource/missing.md"}}`)
+	if msg := read(); msg["error"] == nil {
+		t.Fatalf("expected an unknown resource to be rejected, got %#v", msg)
+	}
+}
+
+func TestMCPResourceSubscribeRequiresSession(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	allino.MCPExtension.Config.Stateless = true
+	resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"allino://users/42"}}`, nil)
+	*allino.MCPExtension.Config = saved
+	var out struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Error == nil || !strings.Contains(out.Error.Message, "session") {
+		t.Fatalf("expected a session error, got %#v", out.Error)
+	}
+
+	session, _ := mcpInitialize(t, "2025-06-18")
+	resp = mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"allino://users/42"}}`, map[string]string{"Mcp-Session-Id": session})
+	body, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(body), `"error"`) {
+		t.Fatalf("unexpected subscribe error %s", body)
+	}
+}
+
+func TestMCPWatchDirs(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	dir := t.TempDir()
+	resources := filepath.Join(dir, "resources")
+	prompts := filepath.Join(dir, "prompts")
+	os.MkdirAll(resources, 0o755)
+	os.MkdirAll(prompts, 0o755)
+	config, _ := json.Marshal(map[string]any{"mcp": map[string]any{
+		"resourceDirs": []string{resources},
+		"promptDirs":   []string{prompts},
+	}})
+	srv := allino.NewTestServer(&allino.Config{
+		ConfigBytes: config,
+		SQL: allino.SQLConfig{
+			Driver: "sqlite",
+		},
+	})
+
+	write, read := mcpStdioClientOf(t, srv)
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	read()
+
+	os.WriteFile(filepath.Join(resources, "guide.md"), []byte("v1"), 0o644)
+	os.WriteFile(filepath.Join(prompts, "review.md"), []byte("Review it."), 0o644)
+	got := map[any]bool{}
+	for len(got) < 2 {
+		got[read()["method"]] = true
+	}
+	if !got["notifications/resources/list_changed"] || !got["notifications/prompts/list_changed"] {
+		t.Fatalf("unexpected notifications %#v", got)
+	}
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"allino://resource/guide.md"}}`)
+	read()
+	os.WriteFile(filepath.Join(resources, "guide.md"), []byte("version 2"), 0o644)
+	msg := read()
+	if msg["method"] != "notifications/resources/updated" || msg["params"].(map[string]any)["uri"] != "allino://resource/guide.md" {
+		t.Fatalf("unexpected notification %#v", msg)
+	}
+}
+
+func TestMCPWatchDirsUnreadable(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	dir := t.TempDir()
+	resources := filepath.Join(dir, "resources")
+	os.MkdirAll(resources, 0o755)
+	os.WriteFile(filepath.Join(resources, "guide.md"), []byte("v1"), 0o644)
+	config := []byte("mcp:\n  resourceDirs: [" + strconv.Quote(resources) + "]\n  watchInterval: 200ms\n")
+	srv := allino.NewTestServer(&allino.Config{
+		ConfigBytes: config,
+		SQL: allino.SQLConfig{
+			Driver: "sqlite",
+		},
+	})
+
+	write, read := mcpStdioClientOf(t, srv)
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	read()
+
+	// a directory that is briefly missing is not reported as removed files
+	moved := filepath.Join(dir, "moved")
+	os.Rename(resources, moved)
+	time.Sleep(time.Second)
+	os.Rename(moved, resources)
+	time.Sleep(time.Second)
+
+	srv.NotifyMCP("notifications/tools/list_changed", nil)
+	if msg := read(); msg["method"] != "notifications/tools/list_changed" {
+		t.Fatalf("expected no resource notification, got %#v", msg)
+	}
+}
@@ -0,0 +1,95 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+)
+
+func TestMCPPagination(t *testing.T) {
+	all := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if all["nextCursor"] != nil {
+		t.Fatalf("expected one page by default, got cursor %v", all["nextCursor"])
+	}
+	total := len(all["tools"].([]any))
+
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+	allino.MCPExtension.Config.PageSize = 2
+
+	names := []string{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > total {
+			t.Fatal("pagination does not end")
+		}
+		params, _ := json.Marshal(map[string]any{"cursor": cursor})
+		result := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":`+string(params)+`}`)
+		tools := result["tools"].([]any)
+		if len(tools) > 2 {
+			t.Fatalf("expected at most 2 tools per page, got %d", len(tools))
+		}
+		for _, tool := range tools {
+			names = append(names, tool.(map[string]any)["name"].(string))
+		}
+		next, _ := result["nextCursor"].(string)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(names) != total {
+		t.Errorf("expected %d tools over all pages, got %d: %v", total, len(names), names)
+	}
+
+	resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"cursor":"!!"}}`, mcpSession(t))
+	var out struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&out)
+	if out.Error == nil || !strings.Contains(out.Error.Message, "invalid cursor") {
+		t.Errorf("expected an invalid cursor error, got %#v", out.Error)
+	}
+}
+
+func mcpCompletionValues(t *testing.T, params string) []string {
+	t.Helper()
+	result := mcpResult(t, `{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":`+params+`}`)
+	completion := result["completion"].(map[string]any)
+	values := []string{}
+	for _, v := range completion["values"].([]any) {
+		values = append(values, v.(string))
+	}
+	if int(completion["total"].(float64)) != len(values) {
+		t.Errorf("unexpected total %v for %v", completion["total"], values)
+	}
+	return values
+}
+
+func TestMCPCompletion(t *testing.T) {
+	values := mcpCompletionValues(t, `{"ref":{"type":"ref/prompt","name":"mcp_greeting"},"argument":{"name":"tone","value":"f"}}`)
+	if strings.Join(values, ",") != "formal,friendly,funny" {
+		t.Errorf("unexpected enum completion %v", values)
+	}
+	values = mcpCompletionValues(t, `{"ref":{"type":"ref/prompt","name":"mcp_greeting"},"argument":{"name":"tone","value":"FR"}}`)
+	if strings.Join(values, ",") != "friendly" {
+		t.Errorf("unexpected enum completion %v", values)
+	}
+	values = mcpCompletionValues(t, `{"ref":{"type":"ref/prompt","name":"mcp_greeting"},"argument":{"name":"name","value":""}}`)
+	if len(values) != 0 {
+		t.Errorf("expected no values without enum, got %v", values)
+	}
+
+	values = mcpCompletionValues(t, `{"ref":{"type":"ref/resource","uri":"allino://users/{id}{?expand,lang}"},"argument":{"name":"id","value":"4"}}`)
+	if strings.Join(values, ",") != "42" {
+		t.Errorf("unexpected function completion %v", values)
+	}
+	values = mcpCompletionValues(t, `{"ref":{"type":"ref/prompt","name":"unknown"},"argument":{"name":"x","value":""}}`)
+	if len(values) != 0 {
+		t.Errorf("expected no values for an unknown prompt, got %v", values)
+	}
+}
@@ -0,0 +1,107 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+)
+
+// mcpLogMessage returns the message of a notifications/message, "" for other messages.
+func mcpLogMessage(msg map[string]any) string {
+	if msg["method"] != "notifications/message" {
+		return ""
+	}
+	data, _ := msg["params"].(map[string]any)["data"].(map[string]any)
+	text, _ := data["message"].(string)
+	return text
+}
+
+func TestMCPCancelAndLogging(t *testing.T) {
+	write, read := mcpStdioClient(t)
+	write(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	caps := read()["result"].(map[string]any)["capabilities"].(map[string]any)
+	if caps["logging"] == nil {
+		t.Errorf("expected logging capability, got %#v", caps)
+	}
+
+	write(`{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"debug"}}`)
+	if msg := read(); msg["error"] != nil {
+		t.Fatalf("unexpected setLevel error %#v", msg)
+	}
+
+	write(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"mcp_wait","arguments":{"seconds":10}}}`)
+	for {
+		msg := read()
+		if msg["id"] != nil {
+			t.Fatalf("unexpected response before cancel %#v", msg)
+		}
+		if mcpLogMessage(msg) == "mcp_wait started" {
+			break
+		}
+	}
+
+	write(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":3,"reason":"test"}}`)
+	for {
+		msg := read()
+		if msg["id"] != nil {
+			t.Fatalf("unexpected response for a cancelled request %#v", msg)
+		}
+		if mcpLogMessage(msg) == "mcp_wait cancelled" {
+			if level := msg["params"].(map[string]any)["level"]; level != "warning" {
+				t.Errorf("unexpected level %v", level)
+			}
+			break
+		}
+	}
+
+	write(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	for {
+		msg := read()
+		if msg["id"] == float64(3) {
+			t.Fatalf("unexpected response for a cancelled request %#v", msg)
+		}
+		if msg["id"] == float64(4) {
+			break
+		}
+	}
+}
+
+func TestMCPToolCallJobProgress(t *testing.T) {
+	write, read := mcpStdioClient(t)
+	write(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"mcp_async_echo","arguments":{"message":"progress"},"_meta":{"progressToken":"p1"}}}`)
+	for {
+		msg := read()
+		if msg["id"] == nil {
+			continue
+		}
+		result := msg["result"].(map[string]any)
+		structured, _ := result["structuredContent"].(map[string]any)
+		if structured["echo"] != "progress" {
+			t.Fatalf("expected the job output, got %#v", result)
+		}
+		break
+	}
+}
+
+func TestMCPSetLevelRequiresSession(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	allino.MCPExtension.Config.Stateless = true
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"info"}}`, nil)
+	body, _ := io.ReadAll(resp.Body)
+	var out struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Error == nil || !strings.Contains(out.Error.Message, "session") {
+		t.Fatalf("expected a session error, got %s", body)
+	}
+}
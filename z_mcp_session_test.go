@@ -0,0 +1,162 @@
+package allino_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mcpRequest(t *testing.T, method, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, "/mcp", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatalf("MCP request failed: %v", err)
+	}
+	return resp
+}
+
+func mcpInitialize(t *testing.T, version string) (string, string) {
+	t.Helper()
+	resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+version+`"}}`, nil)
+	var out struct {
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Header.Get("Mcp-Session-Id"), out.Result.ProtocolVersion
+}
+
+// mcpSession initializes a session of s for the tests of other methods.
+func mcpSession(t *testing.T) map[string]string {
+	t.Helper()
+	session, _ := mcpInitialize(t, "2025-06-18")
+	return map[string]string{"Mcp-Session-Id": session}
+}
+
+func TestMCPSessionLifecycle(t *testing.T) {
+	session, version := mcpInitialize(t, "2025-03-26")
+	if session == "" || version != "2025-03-26" {
+		t.Fatalf("unexpected session %q version %q", session, version)
+	}
+	if _, version := mcpInitialize(t, "1999-01-01"); version != "2025-06-18" {
+		t.Errorf("expected the latest version for an unknown revision, got %q", version)
+	}
+
+	header := map[string]string{"Mcp-Session-Id": session, "Mcp-Protocol-Version": "2025-03-26"}
+	if resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, header); resp.StatusCode != 200 {
+		t.Fatalf("expected 200 in the session, got %d", resp.StatusCode)
+	}
+	if resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, map[string]string{"Mcp-Session-Id": "unknown"}); resp.StatusCode != 404 {
+		t.Errorf("expected 404 for an unknown session, got %d", resp.StatusCode)
+	}
+	if resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, nil); resp.StatusCode != 400 {
+		t.Errorf("expected 400 without a session, got %d", resp.StatusCode)
+	}
+	if resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`, map[string]string{"Mcp-Protocol-Version": "1999-01-01"}); resp.StatusCode != 400 {
+		t.Errorf("expected 400 for an unsupported protocol version, got %d", resp.StatusCode)
+	}
+
+	// server-initiated messages on the GET stream, until the session is deleted
+	s.NotifyMCP("notifications/tools/list_changed", nil)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		req := httptest.NewRequest("DELETE", "/mcp", nil)
+		req.Header.Set("Mcp-Session-Id", session)
+		_, _ = s.Fiber.Test(req, -1)
+	}()
+	resp := mcpRequest(t, "GET", "", map[string]string{"Mcp-Session-Id": session, "Accept": "text/event-stream"})
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"method":"notifications/tools/list_changed"`) {
+		t.Errorf("expected the notification on the stream, got %s", body)
+	}
+
+	if resp := mcpRequest(t, "DELETE", "", header); resp.StatusCode != 404 {
+		t.Errorf("expected 404 after termination, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPSessionOwner(t *testing.T) {
+	request := func(method, user, session, body string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, "/mcp?.user="+user, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := s.Fiber.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	session := request("POST", "alice", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`).Header.Get("Mcp-Session-Id")
+	if session == "" {
+		t.Fatalf("expected a session")
+	}
+	if resp := request("POST", "bob", session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); resp.StatusCode != 404 {
+		t.Errorf("expected 404 for the session of another user, got %d", resp.StatusCode)
+	}
+	if resp := request("GET", "bob", session, ""); resp.StatusCode != 404 {
+		t.Errorf("expected 404 for the stream of another user, got %d", resp.StatusCode)
+	}
+	if resp := request("DELETE", "bob", session, ""); resp.StatusCode != 404 {
+		t.Errorf("expected 404 when another user deletes the session, got %d", resp.StatusCode)
+	}
+	if resp := request("DELETE", "alice", session, ""); resp.StatusCode != 204 {
+		t.Errorf("expected the owner to delete the session, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPBatch(t *testing.T) {
+	resp := mcpRequest(t, "POST", `[
+		{"jsonrpc":"2.0","id":1,"method":"tools/list"},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"mcp_echo","arguments":{"message":"batch"}}}
+	]`, mcpSession(t))
+	var out []struct {
+		ID     int            `json:"id"`
+		Result map[string]any `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[0].ID != 1 || out[1].ID != 2 {
+		t.Fatalf("unexpected batch response: %#v", out)
+	}
+	if out[1].Result["structuredContent"].(map[string]any)["echo"] != "batch" {
+		t.Errorf("unexpected tool result: %#v", out[1].Result)
+	}
+
+	resp = mcpRequest(t, "POST", `[{"jsonrpc":"2.0","method":"notifications/initialized"}]`, mcpSession(t))
+	if resp.StatusCode != 202 {
+		t.Errorf("expected 202 for a batch of notifications, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPToolCallEventStream(t *testing.T) {
+	header := mcpSession(t)
+	header["Accept"] = "application/json, text/event-stream"
+	resp := mcpRequest(t, "POST", `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"mcp_echo","arguments":{"message":"sse"}}}`, header)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected an event stream, got %q", resp.Header.Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"id":7`) || !strings.Contains(string(body), `"echo":"sse"`) {
+		t.Errorf("unexpected stream: %s", body)
+	}
+}
@@ -0,0 +1,87 @@
+package allino_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMCPStdio(t *testing.T) {
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"mcp_echo","arguments":{"message":"stdio"}}}`,
+		`[{"jsonrpc":"2.0","id":3,"method":"tools/list"},{"jsonrpc":"2.0","id":4,"method":"prompts/list"}]`,
+		`not json`,
+	}, "\n"))
+	var out bytes.Buffer
+	if err := s.ServeMCPStdio(in, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := map[string]map[string]any{}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[") {
+			var batch []map[string]any
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				t.Fatalf("invalid batch line %s: %v", line, err)
+			}
+			if len(batch) != 2 {
+				t.Errorf("expected 2 batch responses, got %s", line)
+			}
+			responses["batch"] = batch[0]
+			continue
+		}
+		var msg map[string]any
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("invalid line %s: %v", line, err)
+		}
+		id, _ := json.Marshal(msg["id"])
+		responses[string(id)] = msg
+	}
+	if len(lines) != 4 {
+		t.Errorf("expected 4 lines, got %d:\n%s", len(lines), out.String())
+	}
+
+	if v := responses["1"]["result"].(map[string]any)["protocolVersion"]; v != "2025-03-26" {
+		t.Errorf("unexpected protocol version %v", v)
+	}
+	structured := responses["2"]["result"].(map[string]any)["structuredContent"].(map[string]any)
+	if structured["echo"] != "stdio" {
+		t.Errorf("unexpected tool result %#v", structured)
+	}
+	if responses["batch"]["result"].(map[string]any)["tools"] == nil {
+		t.Errorf("unexpected tools/list result %#v", responses["batch"])
+	}
+	if responses["null"]["error"] == nil {
+		t.Errorf("expected a parse error, got %#v", responses["null"])
+	}
+}
+
+func TestMCPStdioNotify(t *testing.T) {
+	inr, inw := io.Pipe()
+	outr, outw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- s.ServeMCPStdio(inr, outw) }()
+
+	reader := bufio.NewReader(outr)
+	io.WriteString(inw, `{"jsonrpc":"2.0","id":1,"method":"initialize"}`+"\n")
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, `"id":1`) {
+		t.Fatalf("unexpected initialize response %q %v", line, err)
+	}
+
+	s.NotifyMCP("notifications/resources/list_changed", nil)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "notifications/resources/list_changed") {
+		t.Fatalf("unexpected notification %q %v", line, err)
+	}
+
+	inw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
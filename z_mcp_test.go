@@ -7,15 +7,20 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/rs/xid"
 	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/alltest"
 )
 
 func postMCP(t *testing.T, body string) map[string]any {
 	t.Helper()
 	req := httptest.NewRequest("POST", "/mcp", bytes.NewBufferString(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mcp-Session-Id", mcpSession(t)["Mcp-Session-Id"])
 	resp, err := s.Fiber.Test(req, -1)
 	if err != nil {
 		t.Fatalf("MCP request failed: %v", err)
@@ -47,6 +52,15 @@ func TestMCPToolsList(t *testing.T) {
 			if tool["outputSchema"] == nil {
 				t.Fatalf("expected outputSchema for mcp_echo")
 			}
+			input := tool["inputSchema"].(map[string]any)
+			message := input["properties"].(map[string]any)["message"].(map[string]any)
+			if message["description"] != "Message to echo" {
+				t.Fatalf("expected field description, got %#v", message)
+			}
+			examples, _ := input["examples"].([]any)
+			if len(examples) != 1 || examples[0].(map[string]any)["message"] != "hello" {
+				t.Fatalf("expected input examples, got %#v", input["examples"])
+			}
 		}
 	}
 	if !found {
@@ -67,7 +81,30 @@ func TestMCPToolsCall(t *testing.T) {
 	}
 }
 
+func TestMCPToolsCallAsync(t *testing.T) {
+	message := "async-" + xid.New().String()
+	body := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"mcp_async_echo","arguments":{"message":"` + message + `"}}}`
+	result := postMCP(t, body)["result"].(map[string]any)
+	meta, ok := result["_meta"].(map[string]any)
+	if !ok || meta["allino/status"] != "pending" || meta["allino/jobid"] == "" {
+		t.Fatalf("expected a pending job handle, got %#v", result)
+	}
+
+	poll := `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"mcp_async_echo","_meta":{"allino/jobid":"` + meta["allino/jobid"].(string) + `"}}}`
+	time.Sleep(2 * time.Second)
+
+	result = postMCP(t, poll)["result"].(map[string]any)
+	structured, ok := result["structuredContent"].(map[string]any)
+	if !ok || structured["echo"] != message {
+		t.Fatalf("expected the job result, got %#v", result)
+	}
+}
+
 func TestMCPMarkdownPrompts(t *testing.T) {
+	// the extension config is global, the test server below replaces it
+	saved := *allino.MCPExtension.Config
+	defer func() { *allino.MCPExtension.Config = saved }()
+
 	dir := t.TempDir()
 	resourceDir := t.TempDir()
 	err := os.WriteFile(filepath.Join(dir, "with-frontmatter.md"), []byte(`---
@@ -96,14 +133,21 @@ Use the mounted prompt body.
 		},
 	})
 
+	session := ""
 	post := func(body string) map[string]any {
 		t.Helper()
 		req := httptest.NewRequest("POST", "/mounted_mcp", bytes.NewBufferString(body))
 		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
 		resp, err := server.Fiber.Test(req, -1)
 		if err != nil {
 			t.Fatalf("MCP request failed: %v", err)
 		}
+		if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+			session = id
+		}
 		defer resp.Body.Close()
 		if resp.StatusCode != 200 {
 			bodybuf, _ := io.ReadAll(resp.Body)
@@ -116,6 +160,7 @@ Use the mounted prompt body.
 		return out
 	}
 
+	post(`{"jsonrpc":"2.0","id":0,"method":"initialize"}`)
 	listOut := post(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`)
 	prompts := listOut["result"].(map[string]any)["prompts"].([]any)
 	foundFrontmatter := false
@@ -169,3 +214,111 @@ Use the mounted prompt body.
 		t.Fatalf("unexpected resource body: %#v", resourceContent)
 	}
 }
+
+func TestMCPRequireAuth(t *testing.T) {
+	saved := *allino.MCPExtension.Config
+	allino.MCPExtension.Config.RequireAuth = true
+	allino.MCPExtension.Config.Scopes = []string{"mcp"}
+	defer func() { *allino.MCPExtension.Config = saved }()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	req := httptest.NewRequest("POST", "/mcp", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 401 {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.Contains(challenge, `resource_metadata="http://example.com/.well-known/oauth-protected-resource/mcp"`) {
+		t.Fatalf("unexpected WWW-Authenticate: %q", challenge)
+	}
+
+	resp, err = s.Fiber.Test(httptest.NewRequest("GET", "/.well-known/oauth-protected-resource/mcp", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata struct {
+		Resource             string   `json:"resource"`
+		AuthorizationServers []string `json:"authorization_servers"`
+		ScopesSupported      []string `json:"scopes_supported"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Resource != "http://example.com/mcp" || len(metadata.AuthorizationServers) != 1 || len(metadata.ScopesSupported) != 1 {
+		t.Errorf("unexpected metadata: %#v", metadata)
+	}
+
+	token := allino.IssueAccessToken(allino.NewRuntime(s, nil), "alice", "Alice", nil)
+	req = httptest.NewRequest("POST", "/mcp", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200 with an access token, got %d", resp.StatusCode)
+	}
+
+	// only the bearer token is accepted, not a login cookie or the debug user
+	fakeReq := alltest.NewTestRequest(s)
+	req = httptest.NewRequest("POST", "/mcp?.user=alice", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSRF-Token", allino.IssueCSRFToken(fakeReq, "alice"))
+	req.AddCookie(alltest.FiberToHTTPCookie(allino.IssueLoginCookie(fakeReq, "alice", "Alice")))
+	resp, err = s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 401 {
+		t.Fatalf("expected 401 without an access token, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPResourceTemplates(t *testing.T) {
+	out := postMCP(t, `{"jsonrpc":"2.0","id":1,"method":"resources/templates/list"}`)
+	templates := out["result"].(map[string]any)["resourceTemplates"].([]any)
+	found := false
+	for _, raw := range templates {
+		tmpl := raw.(map[string]any)
+		if tmpl["name"] == "users" {
+			found = true
+			if tmpl["uriTemplate"] != "allino://users/{id}{?expand,lang}" {
+				t.Errorf("unexpected uriTemplate %v", tmpl["uriTemplate"])
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("users template not listed: %#v", templates)
+	}
+
+	out = postMCP(t, `{"jsonrpc":"2.0","id":2,"method":"resources/list"}`)
+	for _, raw := range out["result"].(map[string]any)["resources"].([]any) {
+		if raw.(map[string]any)["name"] == "users" {
+			t.Errorf("templated resource listed as a concrete resource")
+		}
+	}
+
+	out = postMCP(t, `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"allino://users/42?expand=true&lang=ja"}}`)
+	contents := out["result"].(map[string]any)["contents"].([]any)[0].(map[string]any)
+	var user struct {
+		ID     int    `json:"id"`
+		Expand bool   `json:"expand"`
+		Lang   string `json:"lang"`
+	}
+	if err := json.Unmarshal([]byte(contents["text"].(string)), &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 42 || !user.Expand || user.Lang != "ja" {
+		t.Errorf("unexpected input from the URI: %+v", user)
+	}
+
+	out = postMCP(t, `{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"allino://users/abc"}}`)
+	if out["error"] == nil {
+		t.Errorf("expected an error for an invalid path variable, got %#v", out)
+	}
+}
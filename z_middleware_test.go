@@ -0,0 +1,193 @@
+package allino_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/alltest"
+)
+
+type middlewareTestInput struct {
+	Name string `query:"name" json:"name"`
+}
+
+func TestMiddleware(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	var calls []string
+	record := func(name string) allino.Middleware {
+		return func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+			calls = append(calls, name+":"+opt.Name)
+			return next()
+		}
+	}
+
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.Use(record("server"))
+
+	group := allino.Group("/mw", allino.Option{Middleware: []allino.Middleware{record("group")}})
+	fn := allino.NewFunction(
+		allino.Option{
+			Group:       group,
+			Name:        "mw_hello",
+			Path:        "/hello",
+			ContentType: allino.JSON,
+			Middleware:  []allino.Middleware{record("option")},
+		},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, error) {
+			calls = append(calls, "handler")
+			return "hello " + in.Name, nil
+		})
+	srv.TypedHandle(fn)
+
+	resp, err := srv.Fiber.Test(httptest.NewRequest("GET", "/mw/hello?name=http", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 || !strings.Contains(string(body), "hello http") {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, body)
+	}
+	expected := "server:mw_hello,group:mw_hello,option:mw_hello,handler"
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("HTTP: expected %s, got %v", expected, calls)
+	}
+
+	calls = nil
+	out, err := fn.Call(alltest.NewTestRequest(srv), &middlewareTestInput{Name: "call"})
+	if err != nil || out != "hello call" {
+		t.Fatalf("unexpected call result %q %v", out, err)
+	}
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("Call: expected %s, got %v", expected, calls)
+	}
+
+	// cron invocations
+	calls = nil
+	_, _ = fn.Handlefunc(allino.NewRuntime(srv, nil), &middlewareTestInput{Name: "cron"})
+	if strings.Join(calls, ",") != expected {
+		t.Errorf("cron: expected %s, got %v", expected, calls)
+	}
+}
+
+func TestMiddlewareWrapsResult(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	attempts := 0
+	retry := func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+		out, err := next()
+		if err != nil {
+			out, err = next()
+		}
+		return out, err
+	}
+	fn := allino.NewFunction(
+		allino.Option{Name: "mw_retry", Middleware: []allino.Middleware{retry}},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, error) {
+			attempts++
+			if attempts == 1 {
+				return "", errors.New("temporary")
+			}
+			return "ok", nil
+		})
+
+	out, err := fn.Call(alltest.NewTestRequest(s), &middlewareTestInput{})
+	if err != nil || out != "ok" || attempts != 2 {
+		t.Errorf("expected a retried call, got %q %v after %d attempts", out, err, attempts)
+	}
+
+	override := allino.NewFunction(
+		allino.Option{Name: "mw_override", Middleware: []allino.Middleware{
+			func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+				return "from middleware", nil
+			},
+		}},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, error) {
+			t.Errorf("handler must not run")
+			return "", nil
+		})
+	if out, err := override.Call(alltest.NewTestRequest(s), &middlewareTestInput{}); err != nil || out != "from middleware" {
+		t.Errorf("expected the middleware output, got %q %v", out, err)
+	}
+}
+
+func TestMiddlewareTypedNilError(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	fn := allino.NewFunction(
+		allino.Option{Name: "mw_typed_error", Middleware: []allino.Middleware{
+			func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+				out, err := next()
+				if err != nil {
+					t.Errorf("expected a nil error on success, got %#v", err)
+				}
+				return out, err
+			},
+		}},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, *allino.Error) {
+			return "ok", nil
+		})
+
+	if out, err := fn.Call(alltest.NewTestRequest(s), &middlewareTestInput{}); err != nil || out != "ok" {
+		t.Errorf("unexpected call result %q %v", out, err)
+	}
+}
+
+func TestMiddlewareWrongOutputType(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	fn := allino.NewFunction(
+		allino.Option{Name: "mw_wrong_type", Middleware: []allino.Middleware{
+			func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+				return 42, nil
+			},
+		}},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, error) {
+			return "ok", nil
+		})
+
+	if _, err := fn.Call(alltest.NewTestRequest(s), &middlewareTestInput{}); !errors.Is(err, allino.ErrServerError) {
+		t.Errorf("expected a server error, got %v", err)
+	}
+}
+
+func TestMiddlewareCacheHit(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	wrapped, handled := 0, 0
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+	fn := allino.NewFunction(
+		allino.Option{
+			Name: "mw_cached",
+			Job:  allino.JobOption{Cache: true},
+			Middleware: []allino.Middleware{
+				func(r *allino.Runtime, opt *allino.Option, input any, next func() (any, error)) (any, error) {
+					wrapped++
+					return next()
+				},
+			},
+		},
+		func(r *allino.Runtime, in *middlewareTestInput) (string, error) {
+			handled++
+			return "hello " + in.Name, nil
+		})
+	srv.TypedHandle(fn)
+
+	for range 2 {
+		if out, err := fn.Call(alltest.NewTestRequest(srv), &middlewareTestInput{Name: "cache"}); err != nil || out != "hello cache" {
+			t.Fatalf("unexpected call result %q %v", out, err)
+		}
+	}
+	if wrapped != 2 || handled != 1 {
+		t.Errorf("expected the middleware on both calls and one handler run, got %d and %d", wrapped, handled)
+	}
+}
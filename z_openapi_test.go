@@ -0,0 +1,270 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wh-kuromai/allino"
+)
+
+func TestOpenAPIComponents(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	op := doc.Paths["/test/echo"]["get"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	schema, _ := op.Responses["200"].Content["application/json"].Schema.(map[string]any)
+	data, _ := schema["properties"].(map[string]any)["data"].(map[string]any)
+	if data["$ref"] != "#/components/schemas/EchoAPIOutput" {
+		t.Fatalf("expected $ref to EchoAPIOutput, got %v", schema)
+	}
+
+	if doc.Components == nil || doc.Components.Schemas["EchoAPIOutput"] == nil {
+		t.Fatalf("expected EchoAPIOutput component")
+	}
+	buf, _ := json.Marshal(doc.Components.Schemas["EchoAPIOutput"])
+	if string(buf) != `{"properties":{"echo":{"type":"string"},"startAt":{"format":"date-time","type":"string"},"status":{"type":"string"}},"type":"object"}` {
+		t.Errorf("unexpected EchoAPIOutput schema: %s", buf)
+	}
+}
+
+func TestOpenAPIEnvelope(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	op := doc.Paths["/test/echo"]["get"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	buf, _ := json.Marshal(op.Responses["200"].Content[allino.JSON].Schema)
+	if string(buf) != `{"properties":{"data":{"$ref":"#/components/schemas/EchoAPIOutput"}},"required":["data"],"type":"object"}` {
+		t.Errorf("unexpected success schema: %s", buf)
+	}
+
+	res := op.Responses["400"]
+	if res == nil {
+		t.Fatalf("error response not documented")
+	}
+	buf, _ = json.Marshal(res.Content[allino.JSON].Schema)
+	if string(buf) != `{"properties":{"error":{"$ref":"#/components/schemas/Error"}},"required":["error"],"type":"object"}` {
+		t.Errorf("unexpected error schema: %s", buf)
+	}
+	if doc.Components.Schemas["Error"] == nil {
+		t.Errorf("expected Error component")
+	}
+}
+
+func TestOpenAPIOperationIDs(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	get := doc.Paths["/test/echo"]["get"]
+	post := doc.Paths["/test/echo"]["post"]
+	if get == nil || post == nil {
+		t.Fatalf("expected GET and POST (SubMethod) operations")
+	}
+	if get.OperationID != "runtime_get_test_echo" || post.OperationID != "runtime_post_test_echo" {
+		t.Errorf("unexpected operationIds: %q %q", get.OperationID, post.OperationID)
+	}
+	if len(get.Tags) != 1 || get.Tags[0] != "runtime" {
+		t.Errorf("unexpected tags: %v", get.Tags)
+	}
+
+	if op := doc.Paths["/test/stream"]["get"]; op == nil || op.OperationID != "stream_count" {
+		t.Errorf("expected operationId from Option.Name, got %v", op)
+	}
+
+	seen := map[string]bool{}
+	for _, ops := range doc.Paths {
+		for _, op := range ops {
+			if seen[op.OperationID] {
+				t.Errorf("duplicated operationId %q", op.OperationID)
+			}
+			seen[op.OperationID] = true
+		}
+	}
+}
+
+func TestOpenAPISecurity(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	if doc.Components == nil || doc.Components.SecuritySchemes["bearerAuth"] == nil ||
+		doc.Components.SecuritySchemes["loginCookie"] == nil || doc.Components.SecuritySchemes["csrfToken"] == nil {
+		t.Fatalf("expected security schemes from login config, got %v", doc.Components)
+	}
+
+	op := doc.Paths["/test/authwritable"]["post"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	buf, _ := json.Marshal(op.Security)
+	if string(buf) != `[{"bearerAuth":[]},{"csrfToken":[],"loginCookie":[]}]` {
+		t.Errorf("unexpected security: %s", buf)
+	}
+
+	if op := doc.Paths["/test/echo"]["get"]; op.Security != nil {
+		t.Errorf("expected public operation, got %v", op.Security)
+	}
+}
+
+func TestOpenAPIPathTemplate(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	if _, ok := doc.Paths["/test/pathparam/:mypath"]; ok {
+		t.Errorf("expected fiber path to be converted")
+	}
+	op := doc.Paths["/test/pathparam/{mypath}"]["get"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	found := false
+	for _, p := range op.Parameters {
+		if p.In == "path" && p.Name == "mypath" && p.Required {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected mypath path parameter, got %v", op.Parameters)
+	}
+}
+
+func TestCheckRoutes(t *testing.T) {
+	srv := &allino.Server{Config: &allino.Config{}, Fiber: fiber.New()}
+	noop := func(c *fiber.Ctx) error { return nil }
+
+	srv.TypedHandleFiber(allino.Option{Path: "/users/:id", Method: "GET"}, noop)
+	srv.TypedHandleFiber(allino.Option{Path: "/users/:name", Method: "GET"}, noop)
+	srv.TypedHandleFiber(allino.Option{Path: "/users/me", Method: "GET"}, noop)
+	srv.TypedHandleFiber(allino.Option{Path: "/items", Method: "GET", SubMethod: []string{"POST"}}, noop)
+	srv.TypedHandleFiber(allino.Option{Path: "/items", Method: "POST"}, noop)
+
+	conflicts := srv.CheckRoutes()
+	if len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicts, got %v", conflicts)
+	}
+	got := map[string]bool{}
+	for _, c := range conflicts {
+		got[c.String()] = true
+	}
+	if !got["duplicate route: POST /items"] || !got["ambiguous routes: GET /users/:id and /users/:name"] {
+		t.Errorf("unexpected conflicts: %v", got)
+	}
+}
+
+func TestOpenAPIDocsEndpoint(t *testing.T) {
+	defer func() { *allino.OpenAPIDocsExtension.Config = allino.OpenAPIDocsConfig{} }()
+
+	get := func(srv *allino.Server, path string) (int, string, string) {
+		resp, err := srv.Fiber.Test(httptest.NewRequest("GET", path, nil), -1)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		buf, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(buf)
+	}
+
+	srv := allino.NewTestServer(&allino.Config{
+		ConfigBytes: []byte("openapi:\n  enable: true\n  path: /docs\n  ui: redoc\n"),
+		Debug:       true,
+		SQL:         allino.SQLConfig{Driver: "sqlite"},
+	})
+	// registered after start, e.g. by an extension
+	srv.TypedHandleFiber(allino.Option{Path: "/late/route", Method: "GET"}, func(c *fiber.Ctx) error { return nil })
+
+	status, _, body := get(srv, "/docs.json")
+	if status != 200 {
+		t.Fatalf("expected 200, got %d %s", status, body)
+	}
+	var doc allino.OpenAPI
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.Paths["/test/echo"] == nil || doc.Paths["/late/route"] == nil {
+		t.Errorf("expected live paths, got %v", doc.Paths)
+	}
+
+	if status, ctype, body := get(srv, "/docs.yaml"); status != 200 || ctype != allino.YAML || !strings.Contains(body, "openapi: 3.1.0") {
+		t.Errorf("unexpected yaml response: %d %s %s", status, ctype, body)
+	}
+	if status, ctype, body := get(srv, "/docs"); status != 200 || !strings.HasPrefix(ctype, "text/html") ||
+		!strings.Contains(body, `<redoc spec-url="/docs.json">`) ||
+		!strings.Contains(body, `redoc.standalone.js"`) || strings.Contains(body, "<script>") {
+		t.Errorf("unexpected ui response: %d %s %s", status, ctype, body)
+	}
+	if status, ctype, body := get(srv, "/docs/assets/swagger-init.js"); status != 200 || !strings.HasPrefix(ctype, "text/javascript") ||
+		!strings.Contains(body, "SwaggerUIBundle") {
+		t.Errorf("expected the embedded start script, got %d %s %s", status, ctype, body)
+	}
+	if status, _, _ := get(srv, "/docs/assets/fetch.sh"); status != 404 {
+		t.Errorf("expected only the UI files to be served, got %d", status)
+	}
+
+	hosted := allino.NewTestServer(&allino.Config{
+		ConfigBytes: []byte("openapi:\n  enable: true\n  path: /docs\n  ui: swagger\n  assetsURL: /static/swagger/\n  integrity:\n    swagger-ui-bundle.js: sha384-test\n"),
+		Debug:       true,
+		SQL:         allino.SQLConfig{Driver: "sqlite"},
+	})
+	if _, _, body := get(hosted, "/docs"); !strings.Contains(body, `<link rel="stylesheet" href="/static/swagger/swagger-ui.css">`) ||
+		!strings.Contains(body, `<script src="/static/swagger/swagger-ui-bundle.js" integrity="sha384-test" crossorigin="anonymous"></script>`) ||
+		!strings.Contains(body, `<div id="swagger-ui" data-url="/docs.json">`) ||
+		!strings.Contains(body, `<script src="/docs/assets/swagger-init.js" integrity="sha384-`) {
+		t.Errorf("expected the self-hosted assets, got %s", body)
+	}
+
+	prod := allino.NewTestServer(&allino.Config{
+		ConfigBytes: []byte("openapi:\n  enable: true\n  path: /docs\n"),
+		SQL:         allino.SQLConfig{Driver: "sqlite"},
+	})
+	if status, _, _ := get(prod, "/docs.json"); status != 404 {
+		t.Errorf("expected 404 outside debug mode, got %d", status)
+	}
+}
+
+func TestOpenAPITagsAndExamples(t *testing.T) {
+	doc := s.GenerateOpenAPI()
+
+	op := doc.Paths["/test/schema_example"]["post"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	var name *allino.Parameter
+	for _, p := range op.Parameters {
+		if p.Name == "name" {
+			name = p
+		}
+	}
+	if name == nil {
+		t.Fatalf("name parameter not found")
+	}
+	if name.Description != "Display name" {
+		t.Errorf("expected description, got %q", name.Description)
+	}
+	schema := name.Schema.(map[string]any)
+	if schema["maxLength"] != float64(32) {
+		t.Errorf("expected maxLength from validate tag, got %v", schema)
+	}
+	if ex, _ := schema["examples"].([]any); len(ex) != 1 || ex[0] != "Yotsuba" {
+		t.Errorf("expected example from tag, got %v", schema["examples"])
+	}
+	if ex := name.Examples["example1"]; ex == nil || ex.Value != "Yotsuba" || ex.Summary != "valid input" {
+		t.Errorf("expected parameter example from Option.Examples, got %#v", name.Examples)
+	}
+
+	form := op.RequestBody.Content["application/x-www-form-urlencoded"]
+	email := form.Schema.(map[string]any)["properties"].(map[string]any)["email"].(map[string]any)
+	if email["format"] != "email" || email["description"] != "Contact address" {
+		t.Errorf("expected email format and description, got %v", email)
+	}
+	if ex := form.Examples["example1"]; ex == nil || ex.Value.(map[string]any)["email"] != "yotsuba@example.com" {
+		t.Errorf("expected form example, got %#v", form.Examples)
+	}
+
+	res := op.Responses["200"].Content[allino.JSON]
+	if ex := res.Examples["example1"]; ex == nil ||
+		ex.Value.(map[string]any)["data"].(map[string]any)["message"] != "Hello Yotsuba <yotsuba@example.com>" {
+		t.Errorf("expected wrapped response example, got %#v", res.Examples)
+	}
+}
@@ -0,0 +1,102 @@
+package allino_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wh-kuromai/allino"
+)
+
+func TestResponseFields(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test/response", nil)
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	bodybuf, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("X-Total") != "2" {
+		t.Errorf("expected X-Total 2, got %q", resp.Header.Get("X-Total"))
+	}
+	if tags := resp.Header.Get("X-Tag"); tags != "x, y" {
+		t.Errorf("expected X-Tag x, y, got %q", tags)
+	}
+	if !strings.Contains(resp.Header.Get("Set-Cookie"), "visited=yes") {
+		t.Errorf("expected visited cookie, got %q", resp.Header.Get("Set-Cookie"))
+	}
+	if strings.Contains(string(bodybuf), "X-Total") || !strings.Contains(string(bodybuf), `"items"`) {
+		t.Errorf("unexpected body: %s", bodybuf)
+	}
+
+	req = httptest.NewRequest("GET", "/test/response?created=true", nil)
+	resp, err = s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 201 {
+		t.Errorf("expected status 201, got %d", resp.StatusCode)
+	}
+}
+
+func TestResponseFieldsOpenAPI(t *testing.T) {
+	op := s.GenerateOpenAPI().Paths["/test/response"]["get"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	headers := op.Responses["200"].Headers
+	if headers["X-Total"] == nil || headers["X-Tag"] == nil || headers["Set-Cookie"] == nil {
+		t.Errorf("expected documented response headers, got %v", headers)
+	}
+}
+
+type responseCookieOutput struct {
+	Name    string       `json:"name"`
+	Session fiber.Cookie `json:"-" cookie:"session"`
+}
+
+func TestResponseFieldsCookieValue(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.TypedHandle(allino.NewFunction(
+		allino.Option{Name: "response_cookie", Path: "/response_cookie", ContentType: allino.JSON},
+		func(r *allino.Runtime, input *struct{}) (*responseCookieOutput, error) {
+			return &responseCookieOutput{Name: "yotsuba", Session: fiber.Cookie{Value: "s1", Path: "/app"}}, nil
+		}))
+
+	resp, err := srv.Fiber.Test(httptest.NewRequest("GET", "/response_cookie", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie := resp.Header.Get("Set-Cookie"); !strings.Contains(cookie, "session=s1") || !strings.Contains(cookie, "path=/app") {
+		t.Errorf("expected the session cookie, got %q", cookie)
+	}
+}
+
+type responseVisibleHeaderOutput struct {
+	Total int `json:"total" header:"X-Total"`
+}
+
+func TestResponseFieldsRequireHiddenJSON(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a header field in the JSON body to be rejected")
+		}
+	}()
+	allino.NewFunction(
+		allino.Option{Name: "response_visible_header", ContentType: allino.JSON},
+		func(r *allino.Runtime, input *struct{}) (*responseVisibleHeaderOutput, error) {
+			return &responseVisibleHeaderOutput{}, nil
+		})
+}
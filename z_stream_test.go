@@ -0,0 +1,181 @@
+package allino_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func TestStreamFunctionSSE(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test/stream?count=2", nil)
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, allino.EventStream) {
+		t.Fatalf("expected event stream content type, got %s", ct)
+	}
+
+	bodybuf, _ := io.ReadAll(resp.Body)
+	body := string(bodybuf)
+	if !strings.Contains(body, `data: {"index":1,"text":"item-1"}`) ||
+		!strings.Contains(body, `data: {"index":2,"text":"item-2"}`) {
+		t.Fatalf("expected streamed items, got %q", body)
+	}
+	if !strings.Contains(body, "event: done") {
+		t.Fatalf("expected done event, got %q", body)
+	}
+}
+
+func TestStreamFunctionCall(t *testing.T) {
+	r := allino.NewRuntime(s, nil)
+	out, err := handlers.StreamCountFunction.Call(r, &handlers.StreamCountInput{Count: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 3 || out[2].Text != "item-3" {
+		t.Fatalf("expected 3 collected items, got %#v", out)
+	}
+}
+
+func TestStreamFunctionMCPProgress(t *testing.T) {
+	req := httptest.NewRequest("POST", "/mcp", bytes.NewBufferString(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"stream_count","arguments":{"count":2},"_meta":{"progressToken":"tok"}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Mcp-Session-Id", mcpSession(t)["Mcp-Session-Id"])
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	bodybuf, _ := io.ReadAll(resp.Body)
+	body := string(bodybuf)
+	if strings.Count(body, `"method":"notifications/progress"`) != 2 {
+		t.Fatalf("expected 2 progress notifications, got %q", body)
+	}
+	if !strings.Contains(body, `"progressToken":"tok"`) {
+		t.Fatalf("expected progress token, got %q", body)
+	}
+	if !strings.Contains(body, `"id":7`) || !strings.Contains(body, `item-2`) {
+		t.Fatalf("expected final JSON-RPC response, got %q", body)
+	}
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	last := strings.TrimPrefix(lines[len(lines)-1], "data: ")
+	var res struct {
+		Result struct {
+			StructuredContent struct {
+				Items []handlers.StreamCountOutput `json:"items"`
+			} `json:"structuredContent"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(last), &res); err != nil {
+		t.Fatal(err)
+	}
+	if items := res.Result.StructuredContent.Items; len(items) != 2 || items[1].Text != "item-2" {
+		t.Fatalf("expected the streamed items in the result, got %q", last)
+	}
+}
+
+func TestStreamFunctionMCPOutputSchema(t *testing.T) {
+	req := httptest.NewRequest("POST", "/mcp", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mcp-Session-Id", mcpSession(t)["Mcp-Session-Id"])
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Result struct {
+			Tools []struct {
+				Name         string         `json:"name"`
+				OutputSchema map[string]any `json:"outputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range res.Result.Tools {
+		if tool.Name != "stream_count" {
+			continue
+		}
+		items, _ := tool.OutputSchema["properties"].(map[string]any)["items"].(map[string]any)
+		if tool.OutputSchema["type"] != "object" || items["type"] != "array" {
+			t.Fatalf("expected the items wrapped in an object, got %v", tool.OutputSchema)
+		}
+		return
+	}
+	t.Fatalf("stream_count not listed")
+}
+
+type streamDetachedOutput struct {
+	Detached bool   `json:"detached"`
+	User     string `json:"user"`
+	IP       string `json:"ip"`
+}
+
+func TestStreamFunctionDetachedRuntime(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	srv := allino.NewTestServer(&allino.Config{Debug: true, SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.TypedHandle(allino.NewStreamFunction(
+		allino.Option{Name: "stream_detached", Path: "/stream_detached", Method: "GET"},
+		func(r *allino.Runtime, input *struct{}, stream *allino.Stream[*streamDetachedOutput]) error {
+			uid, _, _, _ := r.User()
+			return stream.Send(&streamDetachedOutput{Detached: r.Fiber() == nil, User: uid, IP: r.ClientIP()})
+		}))
+
+	resp, err := srv.Fiber.Test(httptest.NewRequest("GET", "/stream_detached?.user=yotsuba", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	bodybuf, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(bodybuf), `"detached":true,"user":"yotsuba","ip":"0.0.0.0:0"`) {
+		t.Fatalf("expected the values resolved before streaming, got %q", bodybuf)
+	}
+}
+
+func TestStreamFunctionAuth(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	srv := allino.NewTestServer(&allino.Config{Debug: true, SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.TypedHandle(allino.NewStreamFunction(
+		allino.Option{Name: "stream_auth", Path: "/stream_auth", Method: "GET", Auth: allino.AuthLogin},
+		func(r *allino.Runtime, input *struct{}, stream *allino.Stream[string]) error {
+			return stream.Send("secret")
+		}))
+
+	for path, expected := range map[string]int{"/stream_auth": 401, "/stream_auth?.user=yotsuba": 200} {
+		resp, err := srv.Fiber.Test(httptest.NewRequest("GET", path, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodybuf, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != expected {
+			t.Fatalf("%s: expected status %d, got %d %q", path, expected, resp.StatusCode, bodybuf)
+		}
+		if expected != 200 && strings.Contains(string(bodybuf), "secret") {
+			t.Fatalf("%s: expected no streamed items, got %q", path, bodybuf)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package allino_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func postUpload(t *testing.T, fileType, file string) (int, []byte) {
+	return postUploadTo(t, s, fileType, file)
+}
+
+func postUploadTo(t *testing.T, srv *allino.Server, fileType, file string) (int, []byte) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("note", "hello")
+
+	doc, _ := mw.CreateFormFile("doc", "doc.txt")
+	doc.Write([]byte("spooled document"))
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="file"; filename="file.txt"`)
+	h.Set("Content-Type", fileType)
+	fw, _ := mw.CreatePart(h)
+	fw.Write([]byte(file))
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/test/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := srv.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, buf
+}
+
+func TestStreamingUpload(t *testing.T) {
+	status, buf := postUpload(t, "text/plain", "streamed file")
+	if status != 200 {
+		t.Fatalf("expected status 200, got %d %s", status, buf)
+	}
+
+	var resp allino.APIResponse[handlers.UploadAPIOutput]
+	if err := json.Unmarshal(buf, &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.Note != "hello" {
+		t.Errorf("expected note hello, got %q", resp.Data.Note)
+	}
+	if resp.Data.Doc != "spooled document" || resp.Data.DocName != "doc.txt" {
+		t.Errorf("unexpected spooled doc: %q %q", resp.Data.Doc, resp.Data.DocName)
+	}
+	if resp.Data.File != "streamed file" || resp.Data.FileSize != int64(len("streamed file")) {
+		t.Errorf("unexpected streamed file: %q %d", resp.Data.File, resp.Data.FileSize)
+	}
+}
+
+func TestStreamingUploadLimits(t *testing.T) {
+	status, buf := postUpload(t, "text/plain", strings.Repeat("x", 2000))
+	if status != 413 || !strings.Contains(string(buf), "upload_too_large") {
+		t.Errorf("expected upload_too_large, got %d %s", status, buf)
+	}
+
+	status, buf = postUpload(t, "image/png", "not really a png")
+	if status != 415 || !strings.Contains(string(buf), "upload_mime_type") {
+		t.Errorf("expected upload_mime_type, got %d %s", status, buf)
+	}
+}
+
+func TestStreamingUploadBodyLimit(t *testing.T) {
+	srv := allino.NewTestServer(&allino.Config{
+		Fiber: fiber.Config{BodyLimit: 1024},
+		SQL:   allino.SQLConfig{Driver: "sqlite"},
+	})
+
+	body := `{"name":"` + strings.Repeat("x", 2000) + `","email":"yotsuba@example.com"}`
+	req := httptest.NewRequest("POST", "/test/schema_example", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := srv.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 413 {
+		t.Errorf("expected 413 over the body limit, got %d", resp.StatusCode)
+	}
+
+	// upload routes stream bodies over the limit
+	status, buf := postUploadTo(t, srv, "text/plain", strings.Repeat("x", 900))
+	if status != 200 {
+		t.Errorf("expected the upload over the body limit, got %d %s", status, buf)
+	}
+}
+
+func TestStreamingUploadRegisteredLater(t *testing.T) {
+	saved := allino.FunctionList
+	allino.FunctionList = nil
+	defer func() { allino.FunctionList = saved }()
+
+	srv := allino.NewTestServer(&allino.Config{
+		Fiber: fiber.Config{BodyLimit: 1024},
+		SQL:   allino.SQLConfig{Driver: "sqlite"},
+	})
+	srv.TypedHandle(handlers.UploadAPIFunction)
+
+	status, buf := postUploadTo(t, srv, "text/plain", strings.Repeat("x", 900))
+	if status != 200 {
+		t.Errorf("expected the upload over the body limit, got %d %s", status, buf)
+	}
+}
+
+type uploadMixedInput struct {
+	Doc  *multipart.FileHeader `form:"doc"`
+	File *allino.Upload        `form:"file"`
+}
+
+func TestStreamingUploadMixedFields(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected *multipart.FileHeader next to an Upload field to be rejected")
+		}
+	}()
+	allino.NewFunction(
+		allino.Option{Name: "upload_mixed", Path: "/upload_mixed", Method: "POST", ContentType: allino.JSON},
+		func(r *allino.Runtime, input *uploadMixedInput) (string, error) {
+			return "", nil
+		})
+}
+
+func TestStreamingUploadOpenAPI(t *testing.T) {
+	op := s.GenerateOpenAPI().Paths["/test/upload"]["post"]
+	if op == nil || op.RequestBody == nil || op.RequestBody.Content["multipart/form-data"] == nil {
+		t.Fatalf("expected multipart/form-data request body")
+	}
+}
@@ -0,0 +1,202 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wh-kuromai/allino"
+)
+
+type versionTestInput struct{}
+
+func newVersionTestFunctions(t *testing.T, srv *allino.Server) {
+	n := len(allino.FunctionList)
+	t.Cleanup(func() { allino.FunctionList = allino.FunctionList[:n] })
+
+	srv.TypedHandle(allino.NewFunction(
+		allino.Option{
+			Name:        "version_user",
+			Path:        "/version/user",
+			ContentType: allino.JSON,
+			APIVersion:  "v1",
+			Deprecated:  true,
+			Sunset:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		func(r *allino.Runtime, in *versionTestInput) (string, error) { return "one", nil },
+	))
+	srv.TypedHandle(allino.NewFunction(
+		allino.Option{
+			Name:        "version_user",
+			Path:        "/version/user",
+			ContentType: allino.JSON,
+			APIVersion:  "v2",
+		},
+		func(r *allino.Runtime, in *versionTestInput) (string, error) { return "two", nil },
+	))
+}
+
+func getVersion(t *testing.T, srv *allino.Server, path, version string) (int, string, map[string]string) {
+	req := httptest.NewRequest("GET", path, nil)
+	if version != "" {
+		req.Header.Set("Accept-Version", version)
+	}
+	resp, err := srv.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var out struct {
+		Data string `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out.Data, map[string]string{
+		"Deprecation": resp.Header.Get("Deprecation"),
+		"Sunset":      resp.Header.Get("Sunset"),
+	}
+}
+
+func TestAPIVersionPath(t *testing.T) {
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+	newVersionTestFunctions(t, srv)
+
+	status, body, headers := getVersion(t, srv, "/v1/version/user", "")
+	if status != 200 || body != "one" {
+		t.Fatalf("unexpected v1 response: %d %s", status, body)
+	}
+	if headers["Deprecation"] != "true" || headers["Sunset"] != "Tue, 01 Jan 2030 00:00:00 GMT" {
+		t.Errorf("expected deprecation headers, got %v", headers)
+	}
+
+	status, body, headers = getVersion(t, srv, "/v2/version/user", "")
+	if status != 200 || body != "two" {
+		t.Fatalf("unexpected v2 response: %d %s", status, body)
+	}
+	if headers["Deprecation"] != "" || headers["Sunset"] != "" {
+		t.Errorf("unexpected deprecation headers: %v", headers)
+	}
+
+	doc := srv.GenerateOpenAPIVersion("v1")
+	if doc.Info["version"] != "v1" {
+		t.Errorf("expected info.version v1, got %v", doc.Info["version"])
+	}
+	if op := doc.Paths["/v1/version/user"]["get"]; op == nil || !op.Deprecated {
+		t.Errorf("expected deprecated v1 operation, got %#v", doc.Paths["/v1/version/user"])
+	}
+	if doc.Paths["/v2/version/user"] != nil {
+		t.Errorf("v2 operation in the v1 document")
+	}
+	if doc.Paths["/test/echo"] == nil {
+		t.Errorf("unversioned functions are part of every version")
+	}
+
+	versions := srv.APIVersions()
+	if len(versions) != 2 || versions[0] != "v1" || versions[1] != "v2" {
+		t.Errorf("unexpected versions: %v", versions)
+	}
+}
+
+func TestAPIVersionHeader(t *testing.T) {
+	srv := allino.NewTestServer(&allino.Config{
+		Routing: allino.RoutingConfig{Versioning: allino.VersioningHeader, DefaultVersion: "v2"},
+		SQL:     allino.SQLConfig{Driver: "sqlite"},
+	})
+	newVersionTestFunctions(t, srv)
+
+	for version, expected := range map[string]string{"v1": "one", "1": "one", "v2": "two", "": "two"} {
+		status, body, _ := getVersion(t, srv, "/version/user", version)
+		if status != 200 || body != expected {
+			t.Errorf("Accept-Version %q: unexpected response %d %s", version, status, body)
+		}
+	}
+	if status, _, _ := getVersion(t, srv, "/version/user", "v3"); status != 404 {
+		t.Errorf("expected 404 for an unknown version, got %d", status)
+	}
+
+	for _, c := range srv.CheckRoutes() {
+		if c.Path == "/version/user" {
+			t.Errorf("versions of one path reported as conflict: %s", c)
+		}
+	}
+
+	op := srv.GenerateOpenAPIVersion("v1").Paths["/version/user"]["get"]
+	if op == nil {
+		t.Fatalf("operation not found")
+	}
+	found := false
+	for _, p := range op.Parameters {
+		if p.In == "header" && p.Name == "Accept-Version" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected Accept-Version header parameter, got %#v", op.Parameters)
+	}
+}
+
+func TestAPIVersionJobs(t *testing.T) {
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+
+	var fns []*allino.GenericFunction[*versionTestInput, string, error]
+	for _, v := range []string{"v1", "v2"} {
+		version := v
+		fns = append(fns, allino.NewFunction(
+			allino.Option{
+				Name:        "version_job",
+				Path:        "/version/job",
+				ContentType: allino.JSON,
+				APIVersion:  version,
+				JobMode:     "dispatch",
+				MCP:         "tool",
+			},
+			func(r *allino.Runtime, in *versionTestInput) (string, error) { return version, nil },
+		))
+	}
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+
+	jobids := map[string]bool{}
+	for i, fn := range fns {
+		_, err := fn.Call(allino.NewRuntime(srv, nil), &versionTestInput{})
+		var pending *allino.JobPendingError
+		if !errors.As(err, &pending) {
+			t.Fatalf("expected a pending job, got %v", err)
+		}
+		jobids[pending.JobID] = true
+
+		expected := []string{"v1", "v2"}[i]
+		var out string
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			if out, err = fn.JobResult(allino.NewRuntime(srv, nil), pending.JobID); err == nil {
+				break
+			}
+		}
+		if out != expected {
+			t.Errorf("%s: expected the job result %q, got %q %v", expected, expected, out, err)
+		}
+	}
+	if len(jobids) != 2 {
+		t.Errorf("expected a job per version, got %v", jobids)
+	}
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := srv.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Mcp-Session-Id", resp.Header.Get("Mcp-Session-Id"))
+	resp, err = srv.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"version_job_v1"`) || !strings.Contains(string(body), `"version_job_v2"`) {
+		t.Errorf("expected a MCP tool per version, got %s", body)
+	}
+}
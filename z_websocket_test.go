@@ -0,0 +1,117 @@
+package allino_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fasthttp/websocket"
+	"github.com/wh-kuromai/allino"
+	"github.com/wh-kuromai/allino/example/test/handlers"
+)
+
+func TestWebsocketFunction(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Fiber.Listener(ln)
+	defer ln.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/test/ws/lobby", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"message":"hello","upper":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, buf, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp allino.APIResponse[handlers.WebsocketEchoOutput]
+	if err := json.Unmarshal(buf, &resp); err != nil {
+		t.Fatalf("failed to decode reply: %v", err)
+	}
+	if resp.Data.Echo != "HELLO" || resp.Data.Room != "lobby" {
+		t.Fatalf("unexpected reply: %s", buf)
+	}
+
+	// validation error keeps the connection open
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, buf, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"validation_failed"`) {
+		t.Fatalf("expected error reply, got %s", buf)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"message":"again"}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, buf, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"echo":"again"`) {
+		t.Fatalf("unexpected reply: %s", buf)
+	}
+}
+
+func TestWebsocketFunctionRequiresUpgrade(t *testing.T) {
+	req := httptest.NewRequest("GET", "/test/ws/lobby", nil)
+	resp, err := s.Fiber.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 426 {
+		t.Fatalf("expected status 426, got %d", resp.StatusCode)
+	}
+}
+
+func TestGenerateAsyncAPI(t *testing.T) {
+	doc := s.GenerateAsyncAPI()
+	ch, ok := doc.Channels["/test/ws/{room}"]
+	if !ok {
+		t.Fatalf("websocket channel not found: %v", doc.Channels)
+	}
+	if ch.Parameters["room"] == nil {
+		t.Fatalf("expected room parameter")
+	}
+	if ch.Publish == nil || ch.Publish.Message.Payload == nil {
+		t.Fatalf("expected publish message schema")
+	}
+	if ch.Subscribe == nil || len(ch.Subscribe.Message.OneOf) != 2 {
+		t.Fatalf("expected subscribe output/error messages")
+	}
+	buf, _ := json.Marshal(ch.Subscribe.Message.OneOf)
+	if !strings.Contains(string(buf), `"properties":{"data":{"type":"object","properties":{"echo"`) ||
+		!strings.Contains(string(buf), `"properties":{"error":{"type":"object","properties":{"code"`) {
+		t.Errorf("expected the wrapped frames, got %s", buf)
+	}
+
+	op := s.GenerateOpenAPI().Paths["/test/ws/{room}"]["get"]
+	if op == nil || op.Responses["101"] == nil {
+		t.Fatalf("expected websocket upgrade operation in OpenAPI")
+	}
+
+	n := len(allino.FunctionList)
+	defer func() { allino.FunctionList = allino.FunctionList[:n] }()
+	srv := allino.NewTestServer(&allino.Config{SQL: allino.SQLConfig{Driver: "sqlite"}})
+	srv.TypedHandle(allino.NewWebsocketFunction(
+		allino.Option{Name: "ws_nowrap", Path: "/ws_nowrap", NoWrapJSON: true},
+		func(r *allino.Runtime, msg *handlers.WebsocketEchoInput) (*handlers.WebsocketEchoOutput, error) {
+			return nil, nil
+		}))
+	buf, _ = json.Marshal(srv.GenerateAsyncAPI().Channels["/ws_nowrap"].Subscribe.Message.OneOf)
+	if strings.Contains(string(buf), `"data"`) || !strings.Contains(string(buf), `"echo"`) {
+		t.Errorf("expected the unwrapped frames, got %s", buf)
+	}
+}